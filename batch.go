@@ -0,0 +1,124 @@
+package conveyor
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Micro-batches the inbound edge of a stage, the upstream stage sends slices of parcels flushed
+// once Size parcels are pending or the first pending parcel waited Linger. Process still takes
+// the parcels one at a time and the stage's BufferSize counts batches. Only direct edges are
+// batched, fanout, fanin and priority lanes exchange single parcels.
+type Batching struct {
+	// Maximum number of parcels per batch, disabled when below two
+	Size int
+	// Longest a pending parcel waits for its batch to fill, defaults to DefaultBatchLinger
+	Linger time.Duration
+}
+
+const DefaultBatchLinger = time.Millisecond
+
+// Sending side of a batched edge shared by every goroutine of the upstream stage.
+type batcher struct {
+	mutex   *sync.Mutex
+	size    int
+	linger  time.Duration
+	pending []*Parcel
+	timer   *time.Timer
+	closed  bool
+	batches chan []*Parcel
+	abort   context.Context
+	monitor *monitor
+}
+
+// Batching of the edge into the stage, nil unless it is a direct edge of a batching stage.
+func (factory *factory) batching(i, j int) *Batching {
+	if i == 0 || len(factory.stages[i-1]) != len(factory.stages[i]) || factory.priorityLanes != nil {
+		return nil
+	}
+	stage := factory.stages[i][j]
+	if stage.Batch == nil || stage.Batch.Size < 2 {
+		return nil
+	}
+	return stage.Batch
+}
+
+func newBatcher(abort context.Context, monitor *monitor, batching *Batching, capacity uint) *batcher {
+	batcher := &batcher{
+		mutex:   &sync.Mutex{},
+		size:    batching.Size,
+		linger:  batching.Linger,
+		batches: make(chan []*Parcel, capacity),
+		abort:   abort,
+		monitor: monitor,
+	}
+	if batcher.linger <= 0 {
+		batcher.linger = DefaultBatchLinger
+	}
+	batcher.timer = time.AfterFunc(batcher.linger, batcher.expire)
+	batcher.timer.Stop()
+	return batcher
+}
+
+// Adds the parcel to the pending batch, false when the conveyor aborted.
+func (batcher *batcher) add(parcel *Parcel) bool {
+	batcher.mutex.Lock()
+	defer batcher.mutex.Unlock()
+
+	if batcher.pending == nil {
+		batcher.pending = make([]*Parcel, 0, batcher.size)
+		batcher.timer.Reset(batcher.linger)
+	}
+	batcher.pending = append(batcher.pending, parcel)
+	if len(batcher.pending) < batcher.size {
+		return true
+	}
+
+	batcher.timer.Stop()
+	return batcher.flush()
+}
+
+func (batcher *batcher) expire() {
+	batcher.mutex.Lock()
+	defer batcher.mutex.Unlock()
+
+	if !batcher.closed {
+		batcher.flush()
+	}
+}
+
+// Sends the pending batch, holding the mutex so a full edge blocks every sender.
+func (batcher *batcher) flush() bool {
+	batch := batcher.pending
+	if len(batch) == 0 {
+		return true
+	}
+	batcher.pending = nil
+
+	select {
+	case batcher.batches <- batch:
+		return true
+	default:
+	}
+
+	batcher.monitor.blocked(1)
+	defer batcher.monitor.blocked(-1)
+	select {
+	case batcher.batches <- batch:
+		return true
+	case <-batcher.abort.Done():
+		return false
+	}
+}
+
+// Flushes the pending parcels and closes the edge.
+func (batcher *batcher) close() {
+	batcher.mutex.Lock()
+	defer batcher.mutex.Unlock()
+
+	batcher.timer.Stop()
+	batcher.flush()
+	batcher.closed = true
+	close(batcher.batches)
+}
@@ -0,0 +1,129 @@
+package conveyor
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchedEdgesDeliverEveryParcel(t *testing.T) {
+	runner := New(&Options{CollectResults: true, PooledParcels: true}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= 100 {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddStage(&Stage{
+			MaxScale: 4,
+			Batch:    &Batching{Size: 8},
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Content.(int)%10 == 0 {
+					return Skip
+				}
+				return UnpackData([]int{parcel.Content.(int), parcel.Content.(int)})
+			},
+		}).
+		AddSink(&Stage{
+			Batch: &Batching{Size: 16, Linger: time.Microsecond},
+		}).Build().DispatchWithTimeout(5 * time.Second)
+
+	contents, skipped := make([]int, 0), 0
+	for _, result := range Collect(runner, false) {
+		if result.Err == ErrSkipped {
+			skipped++
+			continue
+		}
+		contents = append(contents, result.Content.(int))
+	}
+	sort.Ints(contents)
+
+	assert.NoError(t, runner.Err())
+	assert.Equal(t, 10, skipped)
+	assert.Len(t, contents, 180)
+	assert.Equal(t, []int{1, 1, 2, 2}, contents[:4])
+}
+
+func TestBatchLingerFlushesPartialBatches(t *testing.T) {
+	received := make(chan struct{})
+	runner := New(nil).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence == 0 {
+					return parcel.Sequence
+				}
+				<-received
+				return Stop
+			},
+		}).
+		AddSink(&Stage{
+			Batch: &Batching{Size: 100, Linger: time.Millisecond},
+			Process: func(parcel *Parcel) interface{} {
+				close(received)
+				return nil
+			},
+		}).Build().DispatchBackground()
+
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("partial batch was never flushed")
+	}
+	runner.Wait()
+}
+
+func TestBatchingOnlyAppliesToDirectEdges(t *testing.T) {
+	batched := func() *Stage { return &Stage{Batch: &Batching{Size: 4}} }
+	topology := New(nil).
+		AddSource(&Stage{}).
+		AddStage(batched()).
+		Fanout(batched(), &Stage{}).
+		AddStages(batched(), batched()).
+		Fanin(batched()).
+		AddSink(&Stage{Batch: &Batching{Size: 1}}).Build().Describe()
+
+	sizes := make(map[string]int)
+	for _, edge := range topology.Edges {
+		sizes[edge.From+"->"+edge.To] = edge.BatchSize
+	}
+	assert.Equal(t, map[string]int{
+		"0.0->1.0": 4,
+		"1.0->2.0": 0, "1.0->2.1": 0,
+		"2.0->3.0": 4, "2.1->3.1": 4,
+		"3.0->4.0": 0, "3.1->4.0": 0,
+		"4.0->5.0": 0,
+	}, sizes)
+
+	topology = New(&Options{PriorityLanes: &PriorityLanes{}}).
+		AddSource(&Stage{}).
+		AddSink(batched()).Build().Describe()
+	assert.Equal(t, 0, topology.Edges[0].BatchSize)
+}
+
+func TestLoadBatchDefinition(t *testing.T) {
+	document := `
+pipeline:
+  - source: {process: count, params: {max: 20}}
+  - stage: {process: double, batch: {size: 4, linger: 2ms}}
+  - sink: {batch: {size: -1, linger: soon}}
+`
+	_, err := newTestRegistry().LoadYAML([]byte(document), nil)
+	assert.EqualError(t, err, "conveyor: invalid definition: pipeline[2].sink.batch.size: must not be negative; pipeline[2].sink.batch.linger: time: invalid duration \"soon\"")
+
+	document = `
+pipeline:
+  - source: {process: count, params: {max: 20}}
+  - stage: {process: double, batch: {size: 4, linger: 2ms}}
+  - sink: {}
+`
+	sink, err := newTestRegistry().LoadYAML([]byte(document), &Options{CollectResults: true})
+	assert.NoError(t, err)
+
+	factory := sink.Build()
+	assert.Equal(t, 4, factory.Describe().Edges[0].BatchSize)
+	assert.Len(t, Collect(factory.DispatchWithTimeout(time.Second), false), 20)
+}
@@ -15,6 +15,10 @@ type builder struct {
 // First segment in pipeline
 type ISource interface {
 	AddSource(stage *Stage) IStage
+	// Several sources merged into the next stage
+	AddSources(stages ...*Stage) IStage
+	// Two sources whose items are matched on a key before reaching the stage
+	JoinSources(left, right, stage *Stage, join *KeyedJoin) IStage
 }
 
 // Single/Fanin segment
@@ -29,6 +33,8 @@ type IStages interface {
 	AddStages(stages ...*Stage) IStages
 	AddSinks(stages ...*Stage) ISink
 	Fanin(stage *Stage) IStage
+	// Fanin emitting one parcel per item once every branch produced its output
+	Join(stage *Stage, policy *JoinPolicy) IStage
 }
 
 // Last segment in 
@@ -53,6 +59,10 @@ func New(opts *Options) ISource {
 		opts.ErrorHandler = NewDefaultErrorHandler(opts.Logger)
 	}
 
+	if opts.Watchdog != nil && opts.Watchdog.Timeout <= 0 {
+		panic(fmt.Sprintf("watchdog timeout '%s' must be positive", opts.Watchdog.Timeout))
+	}
+
 	return &builder{
 		options:      *opts,
 		numSequences: 1,
@@ -85,6 +95,10 @@ func (builder *builder) Fanout(stages ...*Stage) IStages {
 	builder.mutex.Lock()
 	defer builder.mutex.Unlock()
 
+	if size := len(builder.stages); size > 0 && len(builder.stages[size-1]) > 1 {
+		panic(fmt.Sprintf("'%d' sources must be merged into a stage before fanning out", len(builder.stages[size-1])))
+	}
+
 	builder.verifyInput(stages...)
 	builder.stages = append(builder.stages, stages)
 	return builder
@@ -116,9 +130,26 @@ func (builder *builder) Fanin(stage *Stage) IStage {
 }
 
 func (builder *builder) Build() IFactory {
+	builder.verifyStorageKeys()
 	return newFactory(builder)
 }
 
+// Stages persisting their cache are keyed by name, unnamed or same-named stages would share a log.
+func (builder *builder) verifyStorageKeys() {
+	keys := make(map[string]bool)
+	for _, stages := range builder.stages {
+		for _, stage := range stages {
+			if stage.Storage == nil {
+				continue
+			}
+			if keys[stage.storageKey] {
+				panic(fmt.Sprintf("stages persisting their cache must have unique names, storage key '%s' is used twice", stage.storageKey))
+			}
+			keys[stage.storageKey] = true
+		}
+	}
+}
+
 func (builder *builder) verifyInput(stages ...*Stage) {
 	for i, stage := range stages {
 		if stage == nil {
@@ -1,13 +1,210 @@
 package conveyor
 
-import cmap "github.com/orcaman/concurrent-map"
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
+	cmap "github.com/orcaman/concurrent-map"
+)
+
+// Stage local state shared by Init, Process and Dispose. Typed stores are attached with Store.
 type Cache struct {
-	cmap.ConcurrentMap
+	values *StateStore[string, interface{}]
+	mutex  *sync.Mutex
+	stores map[string]interface{}
+	// Encoded typed stores awaiting their first Store call
+	restored map[string][]byte
+	children []*Cache
+}
+
+// Prefix of the persisted items holding a typed store rather than a cache entry.
+const storeItemPrefix = "\x00store:"
+
+// Typed store encodable without knowing its type parameters.
+type persistable interface {
+	export() ([]byte, error)
 }
 
 func newCache() *Cache {
 	return &Cache{
-		ConcurrentMap: cmap.New(),
+		values:   NewStateStore[string, interface{}](nil),
+		mutex:    &sync.Mutex{},
+		stores:   make(map[string]interface{}),
+		restored: make(map[string][]byte),
+	}
+}
+
+// Entries to persist, every typed store encoded as a single item.
+func (cache *Cache) persist() (map[string]interface{}, error) {
+	items := cache.Items()
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	failed := make([]string, 0)
+	for name, data := range cache.restored {
+		items[storeItemPrefix+name] = data
+	}
+	for name, store := range cache.stores {
+		data, err := store.(persistable).export()
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("store '%s': %s", name, err))
+			continue
+		}
+		items[storeItemPrefix+name] = data
+	}
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return items, fmt.Errorf("encoding %s", strings.Join(failed, "; "))
+	}
+	return items, nil
+}
+
+// Restores persisted entries, typed stores are decoded once Store names their types.
+func (cache *Cache) restore(items map[string]interface{}) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	for key, value := range items {
+		if name := strings.TrimPrefix(key, storeItemPrefix); name != key {
+			if data, ok := value.([]byte); ok {
+				cache.restored[name] = data
+			}
+			continue
+		}
+		cache.values.Set(key, value)
+	}
+}
+
+func (cache *Cache) MSet(data map[string]interface{}) {
+	for key, value := range data {
+		cache.values.Set(key, value)
+	}
+}
+
+func (cache *Cache) Set(key string, value interface{}) {
+	cache.values.Set(key, value)
+}
+
+// Inserts or updates the key with the value returned by the callback.
+func (cache *Cache) Upsert(key string, value interface{}, cb cmap.UpsertCb) interface{} {
+	return cache.values.Update(key, func(current interface{}, ok bool) interface{} {
+		return cb(ok, current, value)
+	})
+}
+
+// Sets the key when it is missing, false when it was already present.
+func (cache *Cache) SetIfAbsent(key string, value interface{}) bool {
+	set := false
+	cache.values.Update(key, func(current interface{}, ok bool) interface{} {
+		if ok {
+			return current
+		}
+		set = true
+		return value
+	})
+	return set
+}
+
+func (cache *Cache) Get(key string) (interface{}, bool) {
+	return cache.values.Get(key)
+}
+
+func (cache *Cache) Count() int {
+	return cache.values.Len()
+}
+
+func (cache *Cache) Has(key string) bool {
+	_, ok := cache.values.Get(key)
+	return ok
+}
+
+func (cache *Cache) Remove(key string) {
+	cache.values.Delete(key)
+}
+
+// Removes the key when the callback returns true, returning the callback's result.
+func (cache *Cache) RemoveCb(key string, cb cmap.RemoveCb) bool {
+	store := cache.values
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var value interface{}
+	entry, ok := store.lookup(key)
+	if ok {
+		value = entry.value
+	}
+
+	remove := cb(key, value, ok)
+	if remove && ok {
+		store.remove(store.entries[key])
+	}
+	return remove
+}
+
+// Removes the key and returns its value.
+func (cache *Cache) Pop(key string) (interface{}, bool) {
+	store := cache.values
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry, ok := store.lookup(key)
+	if !ok {
+		return nil, false
+	}
+	store.remove(store.entries[key])
+	return entry.value, true
+}
+
+func (cache *Cache) IsEmpty() bool {
+	return cache.Count() == 0
+}
+
+// Deprecated: use IterBuffered.
+func (cache *Cache) Iter() <-chan cmap.Tuple {
+	return cache.IterBuffered()
+}
+
+// Snapshot of the entries as a buffered channel.
+func (cache *Cache) IterBuffered() <-chan cmap.Tuple {
+	items := cache.Items()
+	tuples := make(chan cmap.Tuple, len(items))
+	for key, value := range items {
+		tuples <- cmap.Tuple{Key: key, Val: value}
 	}
+	close(tuples)
+	return tuples
+}
+
+func (cache *Cache) Clear() {
+	cache.values.Clear()
+}
+
+// Snapshot of the entries as a map.
+func (cache *Cache) Items() map[string]interface{} {
+	items := make(map[string]interface{})
+	cache.values.Range(func(key string, value interface{}) bool {
+		items[key] = value
+		return true
+	})
+	return items
+}
+
+func (cache *Cache) IterCb(fn cmap.IterCb) {
+	cache.values.Range(func(key string, value interface{}) bool {
+		fn(key, value)
+		return true
+	})
+}
+
+func (cache *Cache) Keys() []string {
+	return cache.values.Keys()
+}
+
+func (cache *Cache) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cache.Items())
 }
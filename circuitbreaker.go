@@ -1,6 +1,8 @@
 package conveyor
 
 import (
+	"context"
+	"errors"
 	"math"
 	"math/rand"
 	"runtime/debug"
@@ -14,6 +16,8 @@ const (
 	Static
 )
 
+var ErrTimeout = errors.New("conveyor: process timed out")
+
 type ICircuitBreaker interface {
 	Execute(stage *Stage, parcel *Parcel) interface{}
 }
@@ -26,6 +30,17 @@ type CircuitBreaker struct {
 	Rng             *rand.Rand
 }
 
+// Retry settings overriding a stage's circuit breaker at runtime, see StageHandle.SetRetryPolicy.
+type RetryPolicy struct {
+	NumberOfRetries int
+	Policy          FallbackPolicy
+	Interval        time.Duration
+}
+
+type fault struct {
+	err *Error
+}
+
 func NewDefeaultCircuitBreaker() ICircuitBreaker {
 	return &CircuitBreaker{
 		Enabled:         true,
@@ -40,37 +55,107 @@ func (breaker *CircuitBreaker) execute(stage *Stage, parcel *Parcel, circuit int
 	defer func() {
 		circuit++
 		if err := recover(); err != nil {
-			if err == Skip {
-				result = err
+			failure := &Error{Data: err}
+			if fault, ok := err.(*fault); ok {
+				failure = fault.err
+			} else {
+				failure.Stack = string(debug.Stack())
+			}
+
+			if failure.Data == Skip {
+				result = Skip
 			} else if !breaker.Enabled {
 				return
 			} else if circuit > breaker.NumberOfRetries {
-				stage.ErrorHandler.Handle(stage, parcel, &Error{Data: err, Stack: string(debug.Stack())})
+				stage.ErrorHandler.Handle(stage, parcel, failure)
 				result = Failure
 			} else {
-				<-breaker.NewBackoffTimer(circuit).C
+				duration := breaker.backoff(circuit)
+				done := parcel.monitor.backoff(duration)
+				<-time.NewTimer(duration).C
+				done()
 				result = breaker.execute(stage, parcel, circuit+1)
 			}
 		}
 	}()
 
-	result = stage.Process(parcel)
+	result = breaker.process(stage, parcel)
 	return result
 }
 
+// Runs the process with the stage's timeout, an expired attempt cancels the parcel's context and panics with ErrTimeout.
+func (breaker *CircuitBreaker) process(stage *Stage, parcel *Parcel) interface{} {
+	if stage.Timeout <= 0 {
+		return stage.Process(parcel)
+	}
+
+	ctx, cancel := context.WithTimeout(parcel.Context(), stage.Timeout)
+	defer cancel()
+	attempt := *parcel
+	attempt.ctx = ctx
+
+	outcome := make(chan func() interface{}, 1)
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				failure := &Error{Data: err, Stack: string(debug.Stack())}
+				outcome <- func() interface{} { panic(&fault{err: failure}) }
+			}
+		}()
+
+		result := stage.Process(&attempt)
+		outcome <- func() interface{} { return result }
+	}()
+
+	select {
+	case result := <-outcome:
+		value := result()
+		parcel.Priority, parcel.Meta = attempt.Priority, attempt.Meta
+		// The iterator runs once the attempt is over, with the parcel's context instead of the cancelled one
+		if _, ok := value.(UnpackIter); ok {
+			attempt.ctx = parcel.ctx
+		}
+		return value
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			panic(&fault{err: &Error{Data: ErrTimeout, Timeout: true}})
+		}
+		panic(&fault{err: &Error{Data: ctx.Err()}})
+	}
+}
+
 func (breaker *CircuitBreaker) Execute(stage *Stage, parcel *Parcel) interface{} {
-	return breaker.execute(stage, parcel, 0)
+	return breaker.override(parcel).execute(stage, parcel, 0)
+}
+
+func (breaker *CircuitBreaker) override(parcel *Parcel) *CircuitBreaker {
+	policy := parcel.monitor.retryPolicy()
+	if policy == nil {
+		return breaker
+	}
+
+	return &CircuitBreaker{
+		Enabled:         breaker.Enabled,
+		NumberOfRetries: policy.NumberOfRetries,
+		Policy:          policy.Policy,
+		Interval:        policy.Interval,
+		Rng:             breaker.Rng,
+	}
 }
 
 func (breaker *CircuitBreaker) NewBackoffTimer(circuit int) *time.Timer {
+	return time.NewTimer(breaker.backoff(circuit))
+}
+
+func (breaker *CircuitBreaker) backoff(circuit int) time.Duration {
 	rngScale := (float64(breaker.Rng.Int31n(50)) + 50.0) / 100.0
 	duration := time.Duration(math.Round(float64(breaker.Interval.Nanoseconds()) * rngScale))
 	switch breaker.Policy {
 	case Exponential:
-		return time.NewTimer(duration * time.Duration(circuit*circuit))
+		return duration * time.Duration(circuit*circuit)
 	case Static:
-		return time.NewTimer(duration)
+		return duration
 	default:
-		return time.NewTimer(duration)
+		return duration
 	}
 }
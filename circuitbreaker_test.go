@@ -2,6 +2,7 @@ package conveyor
 
 import (
 	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -44,3 +45,57 @@ func TestCircuitBreaker(t *testing.T) {
 			},
 		}).Build().DispatchWithTimeout(time.Second).Wait()
 }
+
+func TestCircuitBreakerTimeout(t *testing.T) {
+	handler := newRecordingErrorHandler()
+	attempts := int32(0)
+	cancelled := int32(0)
+	New(&Options{ErrorHandler: handler}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= 1 {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddSink(&Stage{
+			Timeout: 10 * time.Millisecond,
+			Process: func(parcel *Parcel) interface{} {
+				atomic.AddInt32(&attempts, 1)
+				<-parcel.Context().Done()
+				atomic.AddInt32(&cancelled, 1)
+				return nil
+			},
+		}).Build().DispatchWithTimeout(time.Second).Wait()
+
+	err := handler.handled()[0]
+	assert.True(t, err.Timeout)
+	assert.Equal(t, ErrTimeout, err.Data)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&cancelled) == 3 }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestCircuitBreakerTimeoutRecoversPanics(t *testing.T) {
+	handler := newRecordingErrorHandler()
+	New(&Options{ErrorHandler: handler}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= 1 {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddSink(&Stage{
+			Timeout: time.Second,
+			Process: func(parcel *Parcel) interface{} {
+				panic("test")
+			},
+		}).Build().DispatchWithTimeout(time.Second).Wait()
+
+	err := handler.handled()[0]
+	assert.False(t, err.Timeout)
+	assert.Equal(t, "test", err.Data)
+	assert.Contains(t, err.Stack, "TestCircuitBreakerTimeoutRecoversPanics")
+}
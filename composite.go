@@ -0,0 +1,195 @@
+package conveyor
+
+import "fmt"
+
+type composite struct {
+	stages  []*Stage
+	options Options
+	flush   bool
+}
+
+// Wraps a chain of stages into a single stage usable inside another conveyor. The inner stages
+// run in the goroutines of the composite stage and thereby share its cancellation and sequence numbering,
+// while logger, error handler and circuit breaker are taken from opts, falling back on the parent's options.
+// Scaling, rate limits, partitions, batching, worker caches and storage belong to the composite stage,
+// setting them on an inner stage or in opts panics when the stage is added.
+func Compose(name string, opts *Options, stages ...*Stage) *Stage {
+	if len(stages) == 0 {
+		panic("composite stage must contain atleast one stage")
+	}
+
+	composite := &composite{
+		stages: stages,
+	}
+	if opts != nil {
+		composite.options = *opts
+	}
+
+	return &Stage{
+		Name:      name,
+		Init:      composite.init,
+		Process:   composite.process,
+		Dispose:   composite.dispose,
+		composite: composite,
+	}
+}
+
+func (composite *composite) tidy(parent *Options) {
+	if composite.options.Logger == nil {
+		composite.options.Logger = parent.Logger
+	} else if composite.options.Logger != parent.Logger {
+		composite.flush = true
+	}
+
+	if composite.options.CircuitBreaker == nil {
+		composite.options.CircuitBreaker = parent.CircuitBreaker
+	}
+
+	if composite.options.ErrorHandler == nil && composite.flush {
+		composite.options.ErrorHandler = NewDefaultErrorHandler(composite.options.Logger)
+	} else if composite.options.ErrorHandler == nil {
+		composite.options.ErrorHandler = parent.ErrorHandler
+	}
+
+	if composite.options.Storage != nil {
+		panic("composite options cannot set 'Storage', only the composite stage's cache is persisted")
+	}
+	if composite.options.Execution == WorkerPool {
+		panic("composite options cannot set 'Execution', the inner stages run within the composite stage")
+	}
+
+	for i, stage := range composite.stages {
+		if stage == nil {
+			panic(fmt.Sprintf("composite argument: %d.%d is nil", len(composite.stages), i))
+		}
+		if field := outerField(stage); field != "" {
+			panic(fmt.Sprintf("composite argument: %d.%d sets '%s', which only applies to the composite stage", len(composite.stages), i, field))
+		}
+		stage.tidy(&composite.options)
+	}
+}
+
+// Field of a stage taking effect on the conveyor only, an inner stage runs within the composite stage.
+func outerField(stage *Stage) string {
+	switch {
+	case stage.MaxScale > 1:
+		return "MaxScale"
+	case stage.RateLimit > 0:
+		return "RateLimit"
+	case stage.Partitions > 1:
+		return "Partitions"
+	case stage.Execution == WorkerPool:
+		return "Execution"
+	case stage.Batch != nil:
+		return "Batch"
+	case stage.WorkerInit != nil:
+		return "WorkerInit"
+	case stage.WorkerDispose != nil:
+		return "WorkerDispose"
+	case stage.Storage != nil:
+		return "Storage"
+	}
+	return ""
+}
+
+func (composite *composite) init(cache *Cache) {
+	cache.children = make([]*Cache, len(composite.stages))
+	for i, stage := range composite.stages {
+		cache.children[i] = newCache()
+		stage.Init(cache.children[i])
+	}
+}
+
+func (composite *composite) dispose(cache *Cache) {
+	for i := len(composite.stages) - 1; i >= 0; i-- {
+		composite.stages[i].Dispose(cache.children[i])
+	}
+}
+
+func (composite *composite) process(parcel *Parcel) interface{} {
+	if composite.flush {
+		defer composite.options.Logger.flush(parcel.Sequence)
+	}
+
+	return composite.chain(parcel, parcel.Content, 0)
+}
+
+func (composite *composite) chain(parcel *Parcel, content interface{}, from int) interface{} {
+	for i := from; i < len(composite.stages); i++ {
+		stage := composite.stages[i]
+		inner := parcel.allocate()
+		*inner = Parcel{
+			Content:  content,
+			Cache:    parcel.Cache.children[i],
+			Worker:   parcel.Worker,
+			Stage:    stage,
+			Logger:   stage.logger,
+			Sequence: parcel.Sequence,
+			Priority: parcel.Priority,
+			Meta:     parcel.Meta,
+			ctx:      parcel.ctx,
+			hops:     parcel.hops,
+			pooled:   parcel.pooled,
+		}
+		result := stage.CircuitBreaker.Execute(stage, inner)
+		parcel.Priority, parcel.Meta = inner.Priority, inner.Meta
+		if value, ok := result.(UnpackIter); ok {
+			// The iterator may still use the inner parcel, it is released once exhausted
+			return composite.stream(parcel, inner, value, i+1)
+		}
+		inner.release()
+
+		switch value := result.(type) {
+		case Unpack:
+			data := make([]interface{}, 0, len(value.Data))
+			for _, child := range value.Data {
+				switch childResult := composite.chain(parcel, child, i+1).(type) {
+				case Unpack:
+					data = append(data, childResult.Data...)
+				case UnpackIter:
+					childResult.Iter(func(content interface{}) bool {
+						data = append(data, content)
+						return true
+					})
+				default:
+					data = append(data, childResult)
+				}
+			}
+			return Unpack{Data: data}
+		case Signal:
+			if value == Skip || value == Failure {
+				return value
+			}
+		}
+		content = result
+	}
+
+	return content
+}
+
+// Chains every child of the iterator through the remaining stages as it is yielded.
+func (composite *composite) stream(parcel, inner *Parcel, value UnpackIter, from int) UnpackIter {
+	return UnpackIter{Iter: func(yield func(content interface{}) bool) {
+		defer inner.release()
+		value.Iter(func(child interface{}) bool {
+			switch result := composite.chain(parcel, child, from).(type) {
+			case Unpack:
+				for _, content := range result.Data {
+					if !yield(content) {
+						return false
+					}
+				}
+				return true
+			case UnpackIter:
+				proceed := true
+				result.Iter(func(content interface{}) bool {
+					proceed = yield(content)
+					return proceed
+				})
+				return proceed
+			default:
+				return yield(result)
+			}
+		})
+	}}
+}
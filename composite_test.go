@@ -0,0 +1,128 @@
+package conveyor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompositeStage(t *testing.T) {
+	numIter := 20
+	handler := newRecordingErrorHandler()
+	disposed := make([]string, 0)
+	enrich := Compose("enrich", &Options{ErrorHandler: handler},
+		&Stage{
+			Name: "split",
+			Process: func(parcel *Parcel) interface{} {
+				value := parcel.Content.(int)
+				return UnpackData([]int{value, -value})
+			},
+			Dispose: func(cache *Cache) { disposed = append(disposed, "split") },
+		},
+		&Stage{
+			Name: "validate",
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Content.(int) == 0 {
+					panic("zero")
+				}
+				return parcel.Content
+			},
+			Dispose: func(cache *Cache) { disposed = append(disposed, "validate") },
+		},
+		&Stage{
+			Name: "normalize",
+			Process: func(parcel *Parcel) interface{} {
+				parcel.Cache.Set("seen", true)
+				return parcel.Content.(int) * 10
+			},
+			Dispose: func(cache *Cache) {
+				assert.True(t, cache.Has("seen"))
+				disposed = append(disposed, "normalize")
+			},
+		})
+
+	runner := New(&Options{CollectResults: true}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= numIter {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddStage(enrich).
+		AddSink(&Stage{}).Build().DispatchWithTimeout(time.Second)
+
+	results := Collect(runner, true)
+	runner.Wait()
+
+	assert.Len(t, results, numIter*2)
+	assert.ErrorIs(t, results[0].Err, ErrFailure)
+	assert.ErrorIs(t, results[1].Err, ErrFailure)
+	for _, result := range results[2:] {
+		assert.NoError(t, result.Err)
+		assert.Equal(t, 0, result.Content.(int)%10)
+	}
+	assert.Len(t, handler.handled(), 2)
+	assert.Equal(t, []string{"normalize", "validate", "split"}, disposed)
+}
+
+func TestCompositeStageInFanout(t *testing.T) {
+	numIter := 10
+	double := func() *Stage {
+		return Compose("double", nil, &Stage{
+			Process: func(parcel *Parcel) interface{} { return parcel.Content.(int) * 2 },
+		}, &Stage{
+			Process: func(parcel *Parcel) interface{} { return parcel.Content.(int) * 2 },
+		})
+	}
+
+	runner := New(&Options{CollectResults: true}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= numIter {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		Fanout(double(), double()).
+		Fanin(&Stage{}).
+		AddSink(&Stage{}).Build().DispatchWithTimeout(time.Second)
+
+	sum := 0
+	for _, result := range Collect(runner, false) {
+		sum += result.Content.(int)
+	}
+	assert.Equal(t, 2*4*(numIter*(numIter-1)/2), sum)
+}
+
+func TestCompositeRejectsOuterFields(t *testing.T) {
+	add := func(opts *Options, inner *Stage) func() {
+		return func() {
+			New(nil).
+				AddSource(&Stage{}).
+				AddStage(Compose("inner", opts, inner)).
+				AddSink(&Stage{})
+		}
+	}
+
+	storage, err := NewFileStorage(t.TempDir())
+	assert.NoError(t, err)
+	for _, inner := range []*Stage{
+		{MaxScale: 4},
+		{RateLimit: 10},
+		{Partitions: 2},
+		{Execution: WorkerPool},
+		{Batch: &Batching{Size: 2}},
+		{WorkerInit: func(worker *Cache) {}},
+		{WorkerDispose: func(worker *Cache) {}},
+		{Storage: storage},
+	} {
+		assert.Panics(t, add(nil, inner))
+	}
+	assert.Panics(t, add(&Options{Storage: storage}, &Stage{}))
+	assert.Panics(t, add(&Options{Execution: WorkerPool}, &Stage{}))
+	assert.NotPanics(t, add(nil, &Stage{MaxScale: 1, Timeout: time.Second}))
+}
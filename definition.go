@@ -0,0 +1,518 @@
+package conveyor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Declarative description of a conveyor, loaded from JSON or YAML.
+type Definition struct {
+	Name     string           `json:"name" yaml:"name"`
+	Pipeline []StepDefinition `json:"pipeline" yaml:"pipeline"`
+}
+
+// A single builder step, exactly one of the fields must be set.
+type StepDefinition struct {
+	Source  *StageDefinition   `json:"source,omitempty" yaml:"source,omitempty"`
+	Sources []*StageDefinition `json:"sources,omitempty" yaml:"sources,omitempty"`
+	Stage   *StageDefinition   `json:"stage,omitempty" yaml:"stage,omitempty"`
+	Fanout  []*StageDefinition `json:"fanout,omitempty" yaml:"fanout,omitempty"`
+	Stages  []*StageDefinition `json:"stages,omitempty" yaml:"stages,omitempty"`
+	Fanin   *StageDefinition   `json:"fanin,omitempty" yaml:"fanin,omitempty"`
+	Join    *StageDefinition   `json:"join,omitempty" yaml:"join,omitempty"`
+	Sink    *StageDefinition   `json:"sink,omitempty" yaml:"sink,omitempty"`
+	Sinks   []*StageDefinition `json:"sinks,omitempty" yaml:"sinks,omitempty"`
+}
+
+type StageDefinition struct {
+	Name           string                    `json:"name" yaml:"name"`
+	Process        string                    `json:"process" yaml:"process"`
+	Params         map[string]interface{}    `json:"params" yaml:"params"`
+	MaxScale       uint                      `json:"maxScale" yaml:"maxScale"`
+	BufferSize     uint                      `json:"bufferSize" yaml:"bufferSize"`
+	RateLimit      float64                   `json:"rateLimit" yaml:"rateLimit"`
+	Partitions     int                       `json:"partitions" yaml:"partitions"`
+	Execution      string                    `json:"execution" yaml:"execution"`
+	Batch          *BatchDefinition          `json:"batch" yaml:"batch"`
+	CircuitBreaker *CircuitBreakerDefinition `json:"circuitBreaker" yaml:"circuitBreaker"`
+	JoinPolicy     *JoinPolicyDefinition     `json:"joinPolicy" yaml:"joinPolicy"`
+}
+
+type CircuitBreakerDefinition struct {
+	Enabled         *bool  `json:"enabled" yaml:"enabled"`
+	NumberOfRetries *int   `json:"numberOfRetries" yaml:"numberOfRetries"`
+	Policy          string `json:"policy" yaml:"policy"`
+	Interval        string `json:"interval" yaml:"interval"`
+}
+
+type BatchDefinition struct {
+	Size   int    `json:"size" yaml:"size"`
+	Linger string `json:"linger" yaml:"linger"`
+}
+
+// Only allowed on 'join' steps.
+type JoinPolicyDefinition struct {
+	Timeout    string `json:"timeout" yaml:"timeout"`
+	LateWindow string `json:"lateWindow" yaml:"lateWindow"`
+	OnSkip     string `json:"onSkip" yaml:"onSkip"`
+	OnFailure  string `json:"onFailure" yaml:"onFailure"`
+	OnTimeout  string `json:"onTimeout" yaml:"onTimeout"`
+}
+
+// Validation error pointing at the offending path of a definition, e.g. 'pipeline[2].fanout[1].process'.
+type DefinitionError struct {
+	Path    string
+	Message string
+}
+
+type DefinitionErrors []*DefinitionError
+
+const (
+	sourceStep  = "source"
+	sourcesStep = "sources"
+	stageStep   = "stage"
+	fanoutStep  = "fanout"
+	stagesStep  = "stages"
+	faninStep   = "fanin"
+	joinStep    = "join"
+	sinkStep    = "sink"
+	sinksStep   = "sinks"
+)
+
+var followingSteps = map[string][]string{
+	"":          {sourceStep, sourcesStep},
+	sourceStep:  {stageStep, sinkStep, fanoutStep},
+	sourcesStep: {stageStep, sinkStep},
+	stageStep:   {stageStep, sinkStep, fanoutStep},
+	faninStep:   {stageStep, sinkStep, fanoutStep},
+	joinStep:    {stageStep, sinkStep, fanoutStep},
+	fanoutStep:  {stagesStep, sinksStep, faninStep, joinStep},
+	stagesStep:  {stagesStep, sinksStep, faninStep, joinStep},
+	sinkStep:    {},
+	sinksStep:   {},
+}
+
+func (err *DefinitionError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Path, err.Message)
+}
+
+func (errs DefinitionErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("conveyor: invalid definition: %s", strings.Join(messages, "; "))
+}
+
+func (errs *DefinitionErrors) add(path, format string, args ...interface{}) {
+	*errs = append(*errs, &DefinitionError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (registry *Registry) LoadJSON(data []byte, opts *Options) (ISink, error) {
+	definition := &Definition{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(definition); err != nil {
+		return nil, fmt.Errorf("conveyor: decoding json definition: %w", err)
+	}
+
+	return registry.Build(definition, opts)
+}
+
+func (registry *Registry) LoadYAML(data []byte, opts *Options) (ISink, error) {
+	definition := &Definition{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(definition); err != nil {
+		return nil, fmt.Errorf("conveyor: decoding yaml definition: %w", err)
+	}
+
+	return registry.Build(definition, opts)
+}
+
+// Validates the definition and wires its stages into a builder ready to be built.
+func (registry *Registry) Build(definition *Definition, opts *Options) (ISink, error) {
+	errs := DefinitionErrors{}
+	kinds := make([]string, len(definition.Pipeline))
+	levels := make([][]*Stage, len(definition.Pipeline))
+	policies := make([]*JoinPolicy, len(definition.Pipeline))
+
+	previous, width := "", 0
+	branches := []*StageDefinition{}
+	// Storage keys of persisting stages and the path of the stage claiming them
+	var keys map[string]string
+	if opts != nil && opts.Storage != nil {
+		keys = make(map[string]string)
+	}
+	for i, step := range definition.Pipeline {
+		path := fmt.Sprintf("pipeline[%d]", i)
+		kind, definitions, ok := step.resolve()
+		if !ok {
+			errs.add(path, "step must declare exactly one of source, sources, stage, fanout, stages, fanin, join, sink or sinks")
+			continue
+		}
+
+		if !isFollowingStep(previous, kind) {
+			if previous == "" {
+				errs.add(path, "'%s' is not allowed as first step, expected 'source' or 'sources'", kind)
+			} else {
+				errs.add(path, "'%s' is not allowed after '%s', expected one of %s", kind, previous, strings.Join(followingSteps[previous], ", "))
+			}
+		}
+
+		switch kind {
+		case fanoutStep:
+			if len(definitions) == 0 {
+				errs.add(path+"."+kind, "fanout must contain atleast one stage")
+			}
+			width = len(definitions)
+			branches = definitions
+		case sourcesStep:
+			if len(definitions) == 0 {
+				errs.add(path+"."+kind, "sources must contain atleast one stage")
+			}
+			width = 1
+		case joinStep:
+			width = 1
+			if name, ok := duplicateName(branches); ok {
+				errs.add(path+".join", "join requires unique branch names, '%s' is used by multiple fanout stages", name)
+			}
+		case stagesStep, sinksStep:
+			if len(definitions) != width {
+				errs.add(path+"."+kind, "expected '%d' stages matching the fanout, received '%d'", width, len(definitions))
+			}
+		default:
+			width = 1
+		}
+
+		kinds[i] = kind
+		levels[i] = make([]*Stage, 0, len(definitions))
+		for j, stageDefinition := range definitions {
+			stagePath := path + "." + kind
+			if step.isList() {
+				stagePath = fmt.Sprintf("%s[%d]", stagePath, j)
+			}
+			levels[i] = append(levels[i], registry.stage(stagePath, stageDefinition, &errs))
+			if stageDefinition == nil {
+				continue
+			}
+			if stageDefinition.JoinPolicy != nil && kind != joinStep {
+				errs.add(stagePath+".joinPolicy", "join policy is only allowed on a 'join' step")
+			}
+			if (kind == sourceStep || kind == sourcesStep) && stageDefinition.Process == "" {
+				errs.add(stagePath+".process", "source requires a process, without one it never stops")
+			}
+			if keys != nil {
+				key := storageKey(definition, opts, stageDefinition)
+				if claimed, ok := keys[key]; ok {
+					errs.add(stagePath+".name", "storage key '%s' is already used by '%s', stages persisting their cache must have unique names", key, claimed)
+				} else {
+					keys[key] = stagePath
+				}
+			}
+		}
+		if kind == joinStep && step.Join != nil {
+			policies[i] = step.Join.JoinPolicy.build(path+".join.joinPolicy", &errs)
+		}
+		previous = kind
+	}
+
+	if previous != sinkStep && previous != sinksStep {
+		errs.add("pipeline", "must end with a 'sink' or 'sinks' step")
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if opts == nil {
+		opts = NewDefaultOptions()
+	}
+	if definition.Name != "" {
+		named := *opts
+		named.Name = definition.Name
+		opts = &named
+	}
+
+	var single IStage
+	var multi IStages
+	var sink ISink
+	for i, kind := range kinds {
+		switch kind {
+		case sourceStep:
+			single = New(opts).AddSource(levels[i][0])
+		case sourcesStep:
+			single = New(opts).AddSources(levels[i]...)
+		case stageStep:
+			single = single.AddStage(levels[i][0])
+		case fanoutStep:
+			multi = single.Fanout(levels[i]...)
+		case stagesStep:
+			multi = multi.AddStages(levels[i]...)
+		case faninStep:
+			single = multi.Fanin(levels[i][0])
+		case joinStep:
+			single = multi.Join(levels[i][0], policies[i])
+		case sinkStep:
+			sink = single.AddSink(levels[i][0])
+		case sinksStep:
+			sink = multi.AddSinks(levels[i]...)
+		}
+	}
+
+	return sink, nil
+}
+
+func (registry *Registry) stage(path string, definition *StageDefinition, errs *DefinitionErrors) *Stage {
+	stage := &Stage{}
+	if definition == nil {
+		errs.add(path, "stage must not be empty")
+		return stage
+	}
+
+	stage.Name = definition.Name
+	stage.MaxScale = definition.MaxScale
+	stage.BufferSize = definition.BufferSize
+	stage.RateLimit = definition.RateLimit
+	stage.Partitions = definition.Partitions
+
+	if definition.MaxScale > MaxScale {
+		errs.add(path+".maxScale", "'%d' exceeds the maximum scale '%d'", definition.MaxScale, MaxScale)
+	}
+
+	if definition.BufferSize > MaxBufferSize {
+		errs.add(path+".bufferSize", "'%d' exceeds the maximum buffer size '%d'", definition.BufferSize, MaxBufferSize)
+	}
+
+	if definition.Partitions < 0 {
+		errs.add(path+".partitions", "must not be negative")
+	} else if definition.Partitions > MaxScale {
+		errs.add(path+".partitions", "'%d' exceeds the maximum scale '%d'", definition.Partitions, MaxScale)
+	}
+
+	switch strings.ToLower(definition.Execution) {
+	case "":
+	case "goroutine":
+		stage.Execution = GoroutinePerParcel
+	case "pool":
+		stage.Execution = WorkerPool
+	default:
+		errs.add(path+".execution", "unknown execution model '%s', expected 'goroutine' or 'pool'", definition.Execution)
+	}
+
+	if definition.RateLimit < 0 {
+		errs.add(path+".rateLimit", "must not be negative")
+	}
+
+	if definition.Batch != nil {
+		stage.Batch = definition.Batch.build(path+".batch", errs)
+	}
+
+	if definition.Process != "" {
+		if factory, ok := registry.lookup(definition.Process); !ok {
+			errs.add(path+".process", "process '%s' is not registered", definition.Process)
+		} else if process, err := factory(definition.Params); err != nil {
+			errs.add(path+".params", "%s", err.Error())
+		} else {
+			stage.Process = process
+		}
+	} else if len(definition.Params) > 0 {
+		errs.add(path+".params", "params are given without a process")
+	}
+
+	if definition.CircuitBreaker != nil {
+		stage.CircuitBreaker = definition.CircuitBreaker.build(path+".circuitBreaker", errs)
+	}
+
+	return stage
+}
+
+func (definition *CircuitBreakerDefinition) build(path string, errs *DefinitionErrors) ICircuitBreaker {
+	breaker := NewDefeaultCircuitBreaker().(*CircuitBreaker)
+
+	if definition.Enabled != nil {
+		breaker.Enabled = *definition.Enabled
+	}
+
+	if definition.NumberOfRetries != nil {
+		if *definition.NumberOfRetries < 0 {
+			errs.add(path+".numberOfRetries", "must not be negative")
+		}
+		breaker.NumberOfRetries = *definition.NumberOfRetries
+	}
+
+	switch strings.ToLower(definition.Policy) {
+	case "", "static":
+		breaker.Policy = Static
+	case "exponential":
+		breaker.Policy = Exponential
+	default:
+		errs.add(path+".policy", "unknown policy '%s', expected 'static' or 'exponential'", definition.Policy)
+	}
+
+	if definition.Interval != "" {
+		interval, err := time.ParseDuration(definition.Interval)
+		if err != nil {
+			errs.add(path+".interval", "%s", err.Error())
+		} else if interval < 0 {
+			errs.add(path+".interval", "must not be negative")
+		}
+		breaker.Interval = interval
+	}
+
+	return breaker
+}
+
+func (definition *BatchDefinition) build(path string, errs *DefinitionErrors) *Batching {
+	batching := &Batching{Size: definition.Size}
+	if definition.Size < 0 {
+		errs.add(path+".size", "must not be negative")
+	} else if definition.Size > MaxBufferSize {
+		errs.add(path+".size", "'%d' exceeds the maximum buffer size '%d'", definition.Size, MaxBufferSize)
+	}
+
+	if definition.Linger != "" {
+		linger, err := time.ParseDuration(definition.Linger)
+		if err != nil {
+			errs.add(path+".linger", "%s", err.Error())
+		} else if linger < 0 {
+			errs.add(path+".linger", "must not be negative")
+		}
+		batching.Linger = linger
+	}
+
+	return batching
+}
+
+func (definition *JoinPolicyDefinition) build(path string, errs *DefinitionErrors) *JoinPolicy {
+	policy := &JoinPolicy{}
+	if definition == nil {
+		return policy
+	}
+
+	if definition.Timeout != "" {
+		timeout, err := time.ParseDuration(definition.Timeout)
+		if err != nil {
+			errs.add(path+".timeout", "%s", err.Error())
+		} else if timeout < 0 {
+			errs.add(path+".timeout", "must not be negative")
+		}
+		policy.Timeout = timeout
+	}
+
+	if definition.LateWindow != "" {
+		window, err := time.ParseDuration(definition.LateWindow)
+		if err != nil {
+			errs.add(path+".lateWindow", "%s", err.Error())
+		} else if window < 0 {
+			errs.add(path+".lateWindow", "must not be negative")
+		}
+		policy.LateWindow = window
+	}
+
+	policy.OnSkip = joinAction(path+".onSkip", definition.OnSkip, errs)
+	policy.OnFailure = joinAction(path+".onFailure", definition.OnFailure, errs)
+	policy.OnTimeout = joinAction(path+".onTimeout", definition.OnTimeout, errs)
+	return policy
+}
+
+func joinAction(path, action string, errs *DefinitionErrors) JoinAction {
+	switch strings.ToLower(action) {
+	case "":
+		return JoinDefault
+	case "omit":
+		return JoinOmit
+	case "skip":
+		return JoinSkip
+	case "failure":
+		return JoinFailure
+	default:
+		errs.add(path, "unknown action '%s', expected 'omit', 'skip' or 'failure'", action)
+		return JoinDefault
+	}
+}
+
+func (step *StepDefinition) resolve() (string, []*StageDefinition, bool) {
+	kinds := make([]string, 0, 1)
+	definitions := []*StageDefinition{}
+	single := map[string]*StageDefinition{
+		sourceStep: step.Source,
+		stageStep:  step.Stage,
+		faninStep:  step.Fanin,
+		joinStep:   step.Join,
+		sinkStep:   step.Sink,
+	}
+	multiple := map[string][]*StageDefinition{
+		sourcesStep: step.Sources,
+		fanoutStep:  step.Fanout,
+		stagesStep:  step.Stages,
+		sinksStep:   step.Sinks,
+	}
+
+	for kind, definition := range single {
+		if definition != nil {
+			kinds = append(kinds, kind)
+			definitions = []*StageDefinition{definition}
+		}
+	}
+
+	for kind, list := range multiple {
+		if list != nil {
+			kinds = append(kinds, kind)
+			definitions = list
+		}
+	}
+
+	if len(kinds) != 1 {
+		return "", nil, false
+	}
+	return kinds[0], definitions, true
+}
+
+// Key the stage's cache is persisted under, matching the key given by Stage.tidy.
+func storageKey(definition *Definition, opts *Options, stage *StageDefinition) string {
+	name, stageName := opts.Name, stage.Name
+	if definition.Name != "" {
+		name = definition.Name
+	}
+	if stageName == "" {
+		stageName = "Unnamed"
+	}
+	return name + "." + stageName
+}
+
+func (step *StepDefinition) isList() bool {
+	return step.Sources != nil || step.Fanout != nil || step.Stages != nil || step.Sinks != nil
+}
+
+func duplicateName(definitions []*StageDefinition) (string, bool) {
+	seen := make(map[string]bool)
+	for _, definition := range definitions {
+		if definition == nil {
+			continue
+		}
+		name := definition.Name
+		if name == "" {
+			name = "Unnamed"
+		}
+		if seen[name] {
+			return name, true
+		}
+		seen[name] = true
+	}
+	return "", false
+}
+
+func isFollowingStep(previous, kind string) bool {
+	for _, following := range followingSteps[previous] {
+		if following == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package conveyor
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRegistry() *Registry {
+	registry := NewRegistry()
+	registry.Register("count", func(params map[string]interface{}) (Process, error) {
+		max, ok := params["max"].(int)
+		if !ok {
+			return nil, fmt.Errorf("'max' must be an integer")
+		}
+		return func(parcel *Parcel) interface{} {
+			if parcel.Sequence >= max {
+				return Stop
+			}
+			return parcel.Sequence
+		}, nil
+	})
+	registry.RegisterProcess("double", func(parcel *Parcel) interface{} {
+		return parcel.Content.(int) * 2
+	})
+	return registry
+}
+
+func TestLoadYAMLDefinition(t *testing.T) {
+	document := `
+name: numbers
+pipeline:
+  - source: {name: numerate, process: count, params: {max: 10}}
+  - fanout:
+      - {name: left, process: double, bufferSize: 5}
+      - name: right
+        maxScale: 4
+        circuitBreaker: {numberOfRetries: 1, policy: exponential, interval: 1ms}
+  - fanin: {name: merge}
+  - sink: {name: write}
+`
+	sink, err := newTestRegistry().LoadYAML([]byte(document), &Options{CollectResults: true})
+	assert.NoError(t, err)
+
+	sum := 0
+	for _, result := range Collect(sink.Build().DispatchWithTimeout(time.Second), false) {
+		sum += result.Content.(int)
+	}
+	assert.Equal(t, 3*45, sum)
+}
+
+func TestLoadJSONDefinition(t *testing.T) {
+	document := `{
+		"pipeline": [
+			{"source": {"process": "count", "params": {"max": 5}}},
+			{"stage": {"process": "double"}},
+			{"sink": {}}
+		]
+	}`
+	registry := NewRegistry()
+	registry.RegisterProcess("count", func(parcel *Parcel) interface{} {
+		if parcel.Sequence >= 5 {
+			return Stop
+		}
+		return parcel.Sequence
+	})
+	registry.RegisterProcess("double", func(parcel *Parcel) interface{} {
+		return parcel.Content.(int) * 2
+	})
+
+	sink, err := registry.LoadJSON([]byte(document), &Options{CollectResults: true})
+	assert.NoError(t, err)
+	assert.Len(t, Collect(sink.Build().DispatchWithTimeout(time.Second), false), 5)
+}
+
+func TestInvalidDefinitionReportsPaths(t *testing.T) {
+	document := `
+pipeline:
+  - source: {process: count, params: {max: ten}}
+  - fanout:
+      - {process: missing}
+      - {maxScale: 20000, circuitBreaker: {policy: linear, interval: soon}}
+  - stages:
+      - {}
+  - sink: {}
+`
+	_, err := newTestRegistry().LoadYAML([]byte(document), nil)
+
+	var errs DefinitionErrors
+	assert.True(t, errors.As(err, &errs))
+	paths := make([]string, 0)
+	for _, err := range errs {
+		paths = append(paths, err.Path)
+	}
+	assert.Equal(t, []string{
+		"pipeline[0].source.params",
+		"pipeline[1].fanout[0].process",
+		"pipeline[1].fanout[1].maxScale",
+		"pipeline[1].fanout[1].circuitBreaker.policy",
+		"pipeline[1].fanout[1].circuitBreaker.interval",
+		"pipeline[2].stages",
+		"pipeline[3]",
+	}, paths)
+}
+
+func TestDefinitionRejectsIdleSourcesAndSharedStorageKeys(t *testing.T) {
+	document := `
+pipeline:
+  - sources:
+      - {process: count, params: {max: 3}}
+      - {}
+  - stage: {name: write}
+  - sink: {name: write}
+`
+	storage, err := NewFileStorage(t.TempDir())
+	assert.NoError(t, err)
+	paths := func(opts *Options) []string {
+		_, err := newTestRegistry().LoadYAML([]byte(document), opts)
+		var errs DefinitionErrors
+		assert.True(t, errors.As(err, &errs))
+		paths := make([]string, 0)
+		for _, err := range errs {
+			paths = append(paths, err.Path)
+		}
+		return paths
+	}
+
+	assert.Equal(t, []string{"pipeline[0].sources[1].process"}, paths(nil))
+	assert.Equal(t, []string{
+		"pipeline[0].sources[1].process",
+		"pipeline[0].sources[1].name",
+		"pipeline[2].sink.name",
+	}, paths(&Options{Storage: storage}))
+}
+
+func TestLoadJoinDefinition(t *testing.T) {
+	document := `
+pipeline:
+  - source: {process: count, params: {max: 4}}
+  - fanout:
+      - {name: left, process: double}
+      - {name: right}
+  - join: {name: merge, joinPolicy: {timeout: 1s, onSkip: omit}}
+  - sink: {}
+`
+	sink, err := newTestRegistry().LoadYAML([]byte(document), &Options{CollectResults: true})
+	assert.NoError(t, err)
+
+	results := Collect(sink.Build().DispatchWithTimeout(time.Second), true)
+	assert.Len(t, results, 4)
+	assert.Equal(t, Joined{"left": 6, "right": 3}, results[3].Content)
+
+	document = `
+pipeline:
+  - source: {process: count, params: {max: 4}, joinPolicy: {}}
+  - fanout: [{}, {}]
+  - join: {joinPolicy: {timeout: later, lateWindow: -1s, onTimeout: retry}}
+  - sink: {}
+`
+	_, err = newTestRegistry().LoadYAML([]byte(document), nil)
+	var errs DefinitionErrors
+	assert.True(t, errors.As(err, &errs))
+	paths := make([]string, 0)
+	for _, err := range errs {
+		paths = append(paths, err.Path)
+	}
+	assert.Equal(t, []string{
+		"pipeline[0].source.joinPolicy",
+		"pipeline[2].join",
+		"pipeline[2].join.joinPolicy.timeout",
+		"pipeline[2].join.joinPolicy.lateWindow",
+		"pipeline[2].join.joinPolicy.onTimeout",
+	}, paths)
+}
+
+func TestLoadSourcesDefinition(t *testing.T) {
+	document := `
+pipeline:
+  - sources:
+      - {process: count, params: {max: 3}}
+      - {process: count, params: {max: 3}}
+  - sink: {}
+`
+	sink, err := newTestRegistry().LoadYAML([]byte(document), &Options{CollectResults: true})
+	assert.NoError(t, err)
+	assert.Len(t, Collect(sink.Build().DispatchWithTimeout(time.Second), false), 3)
+}
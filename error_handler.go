@@ -7,6 +7,8 @@ type ErrorHandler struct {
 type Error struct {
 	Data  interface{}
 	Stack string
+	// Set when the process exceeded the stage's timeout, Data is then ErrTimeout
+	Timeout bool
 }
 
 type IErrorHandler interface {
@@ -0,0 +1,56 @@
+package examples
+
+import (
+	"sync/atomic"
+
+	"github.com/defendable/conveyor"
+)
+
+// Boxed once so the measured allocations are the conveyor's own.
+var payload interface{} = 1
+
+// Runs numItems parcels through the topology, returning how many parcels reached the sinks.
+// 'source' is a source feeding a sink, 'segment' adds a segment in between, 'batched' batches
+// both edges of it and 'fanout' splits the segment's parcels over two branches.
+func allocations(topology string, numItems int, pooled bool) int64 {
+	received := int64(0)
+	source := &conveyor.Stage{
+		Process: func(parcel *conveyor.Parcel) interface{} {
+			if parcel.Sequence >= numItems {
+				return conveyor.Stop
+			}
+			return payload
+		},
+	}
+	sink := func() *conveyor.Stage {
+		return &conveyor.Stage{
+			Process: func(parcel *conveyor.Parcel) interface{} {
+				atomic.AddInt64(&received, 1)
+				return nil
+			},
+		}
+	}
+
+	builder := conveyor.New(&conveyor.Options{PooledParcels: pooled}).AddSource(source)
+	var factory conveyor.IFactory
+	switch topology {
+	case "source":
+		factory = builder.AddSink(sink()).Build()
+	case "segment":
+		factory = builder.AddStage(&conveyor.Stage{}).AddSink(sink()).Build()
+	case "batched":
+		batch := &conveyor.Batching{Size: 32}
+		sink := sink()
+		sink.Batch = batch
+		factory = builder.AddStage(&conveyor.Stage{Batch: batch}).AddSink(sink).Build()
+	case "fanout":
+		factory = builder.AddStage(&conveyor.Stage{}).
+			Fanout(&conveyor.Stage{}, &conveyor.Stage{}).
+			AddSinks(sink(), sink()).Build()
+	default:
+		panic("unknown topology " + topology)
+	}
+
+	factory.DispatchBackground().Wait()
+	return received
+}
@@ -1,13 +1,15 @@
 package examples
 
 import (
-	"fmt"
-
 	"github.com/defendable/conveyor"
 )
 
 func incrementalWork(maxWork int, maxScale, maxBuffer uint) (result int) {
-	conveyor.New(nil).
+	return incrementalWorkWith(maxWork, maxScale, maxBuffer, conveyor.GoroutinePerParcel)
+}
+
+func incrementalWorkWith(maxWork int, maxScale, maxBuffer uint, execution conveyor.ExecutionModel) (result int) {
+	runner := conveyor.New(&conveyor.Options{CollectResults: true, Execution: execution}).
 		AddSource(&conveyor.Stage{
 			Process: func(parcel *conveyor.Parcel) interface{} {
 				if parcel.Sequence > maxWork {
@@ -27,17 +29,12 @@ func incrementalWork(maxWork int, maxScale, maxBuffer uint) (result int) {
 		AddSink(&conveyor.Stage{
 			BufferSize: maxBuffer,
 			MaxScale:   maxScale,
-			Process: func(parcel *conveyor.Parcel) interface{} {
-				parcel.Cache.Set(fmt.Sprintf("%d", parcel.Sequence), parcel.Content)
-				return nil
-			},
-			Dispose: func(cache *conveyor.Cache) {
-				result = 0
-				for _, v := range cache.Items() {
-					result += v.(int)
-				}
-			},
-		}).Build().DispatchBackground().Wait()
+		}).Build().DispatchBackground()
+
+	for _, value := range conveyor.Collect(runner, false) {
+		result += value.Content.(int)
+	}
+	runner.Wait()
 
 	return result
 }
@@ -1,8 +1,11 @@
 package examples
 
 import (
+	"fmt"
+	"runtime"
 	"testing"
 
+	"github.com/defendable/conveyor"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -158,3 +161,59 @@ func BenchmarkTestIncrementalWork_10000_1_2(b *testing.B) {
 		assert.Equal(b, numIncrementalWork10000, result)
 	}
 }
+
+func BenchmarkExecutionModel(b *testing.B) {
+	models := []struct {
+		name      string
+		execution conveyor.ExecutionModel
+	}{
+		{"goroutine", conveyor.GoroutinePerParcel},
+		{"pool", conveyor.WorkerPool},
+	}
+	configs := []struct {
+		work       int
+		scale, buf uint
+		expected   int
+	}{
+		{100, 100, 100, numIncremetnalWork100},
+		{100, 8, 8, numIncremetnalWork100},
+		{1000, 100, 100, numIncrementalWork1000},
+		{1000, 8, 8, numIncrementalWork1000},
+	}
+
+	for _, config := range configs {
+		for _, model := range models {
+			b.Run(fmt.Sprintf("%s_%d_%d_%d", model.name, config.work, config.buf, config.scale), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					result := incrementalWorkWith(config.work, config.scale, config.buf, model.execution)
+					assert.Equal(b, config.expected, result)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkAllocations(b *testing.B) {
+	const numItems = 1000
+	for _, topology := range []string{"source", "segment", "batched", "fanout"} {
+		for _, pooled := range []bool{false, true} {
+			name := topology
+			if pooled {
+				name += "_pooled"
+			}
+			b.Run(name, func(b *testing.B) {
+				b.ReportAllocs()
+				before := &runtime.MemStats{}
+				runtime.ReadMemStats(before)
+				for i := 0; i < b.N; i++ {
+					received := allocations(topology, numItems, pooled)
+					assert.Greater(b, received, int64(numItems-1))
+				}
+				after := &runtime.MemStats{}
+				runtime.ReadMemStats(after)
+				b.ReportMetric(float64(after.Mallocs-before.Mallocs)/float64(b.N*numItems), "allocs/parcel")
+			})
+		}
+	}
+}
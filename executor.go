@@ -0,0 +1,116 @@
+package conveyor
+
+import "sync"
+
+// How a segment or sink runs its Process for the parcels it takes.
+type ExecutionModel int
+
+const (
+	// Inherits Options.Execution, which defaults to GoroutinePerParcel
+	DefaultExecution ExecutionModel = iota
+	// Spawns a goroutine for every parcel, throttled to MaxScale
+	GoroutinePerParcel
+	// Runs MaxScale long-lived workers pulling the parcels
+	WorkerPool
+)
+
+// Runs the work of a segment or sink for every parcel within the stage's scale, either on a
+// goroutine per parcel or on a pool of long-lived workers each owning a worker cache.
+type executor struct {
+	stage   *Stage
+	arg     *stageArg
+	work    func(parcel *Parcel)
+	wg      *sync.WaitGroup
+	jobs    chan *Parcel
+	workers int
+}
+
+func (stage *Stage) newExecutor(arg *stageArg, work func(parcel *Parcel)) *executor {
+	executor := &executor{
+		stage: stage,
+		arg:   arg,
+		work:  work,
+		wg:    &sync.WaitGroup{},
+	}
+
+	if stage.pooled() {
+		executor.jobs = make(chan *Parcel)
+		executor.grow(int(stage.MaxScale))
+	}
+
+	return executor
+}
+
+// Worker caches require workers owning them.
+func (stage *Stage) pooled() bool {
+	return stage.Execution == WorkerPool || stage.WorkerInit != nil || stage.WorkerDispose != nil
+}
+
+// Blocks until the parcel is taken within the stage's rate limit and scale.
+func (executor *executor) execute(parcel *Parcel) {
+	arg := executor.arg
+	if !arg.acquire() {
+		parcel.release()
+		return
+	}
+	arg.monitor.begin()
+
+	if executor.jobs != nil {
+		executor.grow(arg.monitor.semaphore.size())
+		select {
+		case executor.jobs <- parcel:
+		case <-arg.abort.Done():
+			arg.release()
+			arg.monitor.end(parcel)
+			parcel.release()
+		}
+		return
+	}
+
+	executor.wg.Add(1)
+	go func() {
+		defer executor.wg.Done()
+		executor.run(parcel)
+	}()
+}
+
+func (executor *executor) run(parcel *Parcel) {
+	defer parcel.release()
+	defer executor.arg.release()
+	defer executor.arg.monitor.end(parcel)
+	executor.work(parcel)
+}
+
+func (executor *executor) worker() {
+	defer executor.wg.Done()
+
+	cache := newCache()
+	if executor.stage.WorkerInit != nil {
+		executor.stage.WorkerInit(cache)
+	}
+	if executor.stage.WorkerDispose != nil {
+		defer executor.stage.WorkerDispose(cache)
+	}
+
+	for parcel := range executor.jobs {
+		parcel.Worker = cache
+		executor.run(parcel)
+	}
+}
+
+// Starts workers until the pool matches the scale, a raised scale grows the pool while the
+// workers beyond a lowered one stay idle.
+func (executor *executor) grow(scale int) {
+	for ; executor.workers < scale; executor.workers++ {
+		executor.wg.Add(1)
+		go executor.worker()
+	}
+}
+
+// Waits for the taken parcels to finish and disposes the workers.
+func (executor *executor) wait() {
+	if executor.jobs != nil {
+		close(executor.jobs)
+	}
+	executor.wg.Wait()
+}
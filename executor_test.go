@@ -0,0 +1,81 @@
+package conveyor
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerCacheIsolation(t *testing.T) {
+	numIter := 100
+	mutex := &sync.Mutex{}
+	initialized, disposed, written := 0, 0, 0
+	New(nil).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= numIter {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddStage(&Stage{
+			MaxScale: 3,
+			WorkerInit: func(worker *Cache) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				initialized++
+				worker.Set("rows", &[]int{})
+			},
+			Process: func(parcel *Parcel) interface{} {
+				rows, _ := parcel.Worker.Get("rows")
+				*rows.(*[]int) = append(*rows.(*[]int), parcel.Content.(int))
+				time.Sleep(time.Millisecond)
+				return parcel.Content
+			},
+			WorkerDispose: func(worker *Cache) {
+				rows, _ := worker.Get("rows")
+				mutex.Lock()
+				defer mutex.Unlock()
+				disposed++
+				written += len(*rows.(*[]int))
+			},
+		}).
+		AddSink(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				assert.Nil(t, parcel.Worker)
+				return nil
+			},
+		}).Build().DispatchBackground().Wait()
+
+	assert.Equal(t, 3, initialized)
+	assert.Equal(t, 3, disposed)
+	assert.Equal(t, numIter, written)
+}
+
+func TestWorkerPoolExecution(t *testing.T) {
+	numIter := 50
+	stage := &Stage{
+		MaxScale: 4,
+		Process: func(parcel *Parcel) interface{} {
+			assert.NotNil(t, parcel.Worker)
+			return parcel.Content
+		},
+	}
+	runner := New(&Options{Execution: WorkerPool, CollectResults: true}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= numIter {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddStage(stage).
+		AddSink(&Stage{Execution: GoroutinePerParcel}).Build().DispatchWithTimeout(time.Second)
+
+	assert.Len(t, Collect(runner, false), numIter)
+	assert.Equal(t, WorkerPool, stage.Execution)
+}
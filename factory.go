@@ -8,22 +8,35 @@ import (
 )
 
 type factory struct {
-	stages       [][]*Stage
-	numSequences int
-	logger       ILogger
+	name           string
+	stages         [][]*Stage
+	numSequences   int
+	logger         ILogger
+	collectResults bool
+	watchdog       *Watchdog
+	priorityLanes  *PriorityLanes
+	pooledParcels  bool
 }
 
 type IFactory interface {
 	Dispatch(ctx context.Context) *Runner
 	DispatchBackground() *Runner
 	DispatchWithTimeout(duration time.Duration) *Runner
+	Serve(ctx context.Context) *Service
+	Describe() *Topology
 }
 
 func newFactory(builder *builder) IFactory {
 	return &factory{
+		name:         builder.options.Name,
 		stages:       builder.stages,
 		numSequences: builder.numSequences,
 		logger:       builder.options.Logger,
+
+		collectResults: builder.options.CollectResults,
+		watchdog:       builder.options.Watchdog,
+		priorityLanes:  builder.options.PriorityLanes,
+		pooledParcels:  builder.options.PooledParcels,
 	}
 }
 
@@ -44,15 +57,52 @@ func (factory *factory) DispatchWithTimeout(duration time.Duration) *Runner {
 }
 
 func (factory *factory) Dispatch(ctx context.Context) *Runner {
+	return factory.dispatch(ctx, nil)
+}
+
+func (factory *factory) Serve(ctx context.Context) *Service {
+	if len(factory.stages) > 0 && len(factory.stages[0]) != 1 {
+		panic(fmt.Sprintf("serving requires a single source, the conveyor has '%d'", len(factory.stages[0])))
+	}
+
+	service := newService(ctx)
+	service.start(factory.dispatch(service.ctx, service))
+	return service
+}
+
+func (factory *factory) dispatch(ctx context.Context, service *Service) *Runner {
 	if size := len(factory.stages); size <= 1 {
 		panic(fmt.Sprintf("conveyor belt is too short '%d', must be atleast contains two segment", size))
 	}
 
 	wg := &sync.WaitGroup{}
 	bounds := make([]chan *Parcel, 0)
-	flushMsgC := make(chan *flushMessage, 100)
+	abort, cancel := context.WithCancel(context.Background())
+	run := &stageArg{
+		ctx:      ctx,
+		abort:    abort,
+		wg:       wg,
+		factory:  factory,
+		flushMsg: make(chan flushMessage, 100),
+		sinks:    &sync.WaitGroup{},
+		service:  service,
+		batchers: make(map[chan *Parcel]*batcher),
+	}
+	// Served items resolve their futures instead, nothing would drain the results
+	if factory.collectResults && service == nil {
+		run.results = make(chan Result, factory.stages[len(factory.stages)-1][0].BufferSize)
+	}
+	if len(factory.stages[0]) > 1 && factory.stages[1][0].keyedJoin == nil {
+		run.sequencer = &sequencer{}
+	}
+	run.runner = newRunner(wg, run.results)
+	run.runner.name = factory.name
+	run.runner.cancel = cancel
+	run.runner.gate = newGate()
+	run.runner.gates = []*gate{run.runner.gate}
+
 	wg.Add(1)
-	go factory.logger.flusher(wg, flushMsgC, factory.numSequences)
+	go factory.logger.flusher(wg, run.flushMsg, factory.numSequences)
 
 	for i, stages := range factory.stages {
 		if 0 < i && len(factory.stages[i-1]) < len(factory.stages[i]) {
@@ -60,22 +110,45 @@ func (factory *factory) Dispatch(ctx context.Context) *Runner {
 			for _, stage := range stages {
 				outbounds = append(outbounds, make(chan *Parcel, stage.BufferSize))
 			}
-			newMultiplexerConnector(wg, bounds[0], outbounds...)
+			var clone func(*Parcel) *Parcel
+			if factory.pooledParcels {
+				clone = (*Parcel).clone
+			}
+			newMultiplexerConnector(abort, wg, bounds[0], clone, outbounds...)
 			bounds = outbounds
 		} else if 0 < i && len(factory.stages[i-1]) > len(factory.stages[i]) {
 			inbound := []chan *Parcel{make(chan *Parcel, factory.stages[i][0].BufferSize)}
-			newDemultiplexerConnector(wg, inbound[0], bounds...)
+			if keyedJoin := factory.stages[i][0].keyedJoin; keyedJoin != nil {
+				newKeyedJoinConnector(abort, wg, keyedJoin, factory.stages[i][0], inbound[0], bounds[0], bounds[1])
+			} else if join := factory.stages[i][0].join; join != nil {
+				newJoinConnector(abort, wg, join, factory.stages[i][0], service, inbound[0], bounds...)
+			} else {
+				newDemultiplexerConnector(abort, wg, inbound[0], bounds...)
+			}
 			bounds = inbound
 		}
 
 		if len(stages) == 1 {
-			bounds = factory.dispatchSingle(ctx, wg, i, 0, flushMsgC, bounds...)
+			bounds = factory.dispatchSingle(run, i, 0, bounds...)
 		} else {
-			bounds = *factory.dispatchMultiple(ctx, wg, i, 0, flushMsgC, bounds, &[]chan *Parcel{})
+			bounds = *factory.dispatchMultiple(run, i, 0, bounds, &[]chan *Parcel{})
+		}
+	}
+
+	go func() {
+		run.sinks.Wait()
+		if run.results != nil {
+			close(run.results)
 		}
+		close(run.flushMsg)
+	}()
+
+	run.runner.finish()
+	if factory.watchdog != nil {
+		go factory.watchdog.watch(run.runner, factory.logger)
 	}
 
-	return newRunner(wg)
+	return run.runner
 }
 
 func (factory *factory) calculateOutbound(i, j int) chan *Parcel {
@@ -89,40 +162,58 @@ func (factory *factory) calculateOutbound(i, j int) chan *Parcel {
 	return make(chan *Parcel, factory.stages[i+1][j].BufferSize)
 }
 
-func (factory *factory) dispatchSingle(ctx context.Context, wg *sync.WaitGroup, i, j int, flushMsg chan *flushMessage, inbound ...chan *Parcel) []chan *Parcel {
+func (factory *factory) dispatchSingle(run *stageArg, i, j int, inbound ...chan *Parcel) []chan *Parcel {
 	stage := factory.stages[i][j]
 	outbound := factory.calculateOutbound(i, j)
 
-	var inboundC chan *Parcel
+	arg := *run
 	if len(inbound) > 0 {
-		inboundC = inbound[j]
+		arg.inbound = inbound[j]
 	}
-	arg := &stageArg{
-		ctx:      ctx,
-		wg:       wg,
-		factory:  factory,
-		inbound:  inboundC,
-		outbound: outbound,
-		flushMsg: flushMsg,
+	arg.outbound = outbound
+	arg.copies = 1
+	if i < len(factory.stages)-1 && len(factory.stages[i]) < len(factory.stages[i+1]) {
+		arg.copies = len(factory.stages[i+1])
+	}
+	arg.monitor = newMonitor(stage, i, j, arg.inbound, outbound)
+	if batcher, ok := run.batchers[arg.inbound]; ok {
+		arg.batches = batcher.batches
+		arg.monitor.inboundBatches = batcher.batches
+	}
+	if i < len(factory.stages)-1 {
+		if batching := factory.batching(i+1, j); batching != nil {
+			arg.batcher = newBatcher(arg.abort, arg.monitor, batching, factory.stages[i+1][j].BufferSize)
+			arg.monitor.outboundBatches = arg.batcher.batches
+			run.batchers[outbound] = arg.batcher
+		}
+	}
+	if arg.inbound != nil && factory.priorityLanes != nil {
+		arg.inbound = newPrioritizerConnector(arg.abort, arg.wg, arg.inbound, factory.priorityLanes.Capacity, factory.priorityLanes.StarvationLimit)
+	}
+	arg.runner.monitors = append(arg.runner.monitors, arg.monitor)
+	if 0 < i && len(factory.stages[i-1]) < len(factory.stages[i]) {
+		arg.branch = &Hop{Stage: stage.Name, Branch: true, Index: j}
 	}
 
-	if i == 0 {
-		stage.dispatchSource(arg)
+	if i == 0 && arg.service != nil {
+		stage.dispatchServed(&arg)
+	} else if i == 0 {
+		stage.dispatchSource(&arg)
 	} else if 0 < i && i < len(factory.stages)-1 {
-		stage.dispatchSegment(arg)
+		stage.dispatchSegment(&arg)
 	} else {
-		stage.dispatchSink(arg)
+		stage.dispatchSink(&arg)
 	}
 
 	return []chan *Parcel{outbound}
 }
 
-func (factory *factory) dispatchMultiple(ctx context.Context, wg *sync.WaitGroup, i, j int, flushMsg chan *flushMessage, inbound []chan *Parcel, outbound *[]chan *Parcel) *[]chan *Parcel {
+func (factory *factory) dispatchMultiple(run *stageArg, i, j int, inbound []chan *Parcel, outbound *[]chan *Parcel) *[]chan *Parcel {
 	if len(factory.stages[i]) <= j {
 		return outbound
 	}
 
-	*outbound = append(*outbound, factory.dispatchSingle(ctx, wg, i, j, flushMsg, inbound...)...)
+	*outbound = append(*outbound, factory.dispatchSingle(run, i, j, inbound...)...)
 	j++
-	return factory.dispatchMultiple(ctx, wg, i, j, flushMsg, inbound, outbound)
+	return factory.dispatchMultiple(run, i, j, inbound, outbound)
 }
@@ -0,0 +1,51 @@
+package conveyor
+
+import "sync"
+
+// Pausable barrier, the opened channel is closed while the gate is not paused.
+type gate struct {
+	mutex  *sync.Mutex
+	paused bool
+	open   chan struct{}
+}
+
+func newGate() *gate {
+	open := make(chan struct{})
+	close(open)
+	return &gate{
+		mutex: &sync.Mutex{},
+		open:  open,
+	}
+}
+
+func (gate *gate) pause() {
+	gate.mutex.Lock()
+	defer gate.mutex.Unlock()
+
+	if !gate.paused {
+		gate.paused = true
+		gate.open = make(chan struct{})
+	}
+}
+
+func (gate *gate) resume() {
+	gate.mutex.Lock()
+	defer gate.mutex.Unlock()
+
+	if gate.paused {
+		gate.paused = false
+		close(gate.open)
+	}
+}
+
+func (gate *gate) isPaused() bool {
+	gate.mutex.Lock()
+	defer gate.mutex.Unlock()
+	return gate.paused
+}
+
+func (gate *gate) opened() <-chan struct{} {
+	gate.mutex.Lock()
+	defer gate.mutex.Unlock()
+	return gate.open
+}
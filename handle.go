@@ -0,0 +1,72 @@
+package conveyor
+
+// Reconfigures a stage of a running conveyor. Changes apply to parcels taken after the call,
+// parcels already in flight finish with the settings they started with.
+type StageHandle struct {
+	runner   *Runner
+	monitors []*monitor
+}
+
+// Changes the number of parcels processed concurrently, clamped between 1 and MaxScale.
+// Lowering the scale lets in-flight parcels finish before new ones are taken, raising it grows a
+// worker pool to match.
+func (handle *StageHandle) SetMaxScale(scale uint) {
+	if scale > MaxScale {
+		scale = MaxScale
+	}
+	if scale <= 0 {
+		scale = 1
+	}
+
+	for _, monitor := range handle.monitors {
+		monitor.semaphore.resize(int(scale))
+	}
+}
+
+// Limits the stage to the given number of parcels per second, zero removes the limit.
+func (handle *StageHandle) SetRateLimit(perSecond float64) {
+	if perSecond < 0 {
+		perSecond = 0
+	}
+
+	for _, monitor := range handle.monitors {
+		monitor.limiter.setRate(perSecond)
+	}
+}
+
+// Overrides the retry settings of the stage's default circuit breaker, nil restores the configured ones.
+func (handle *StageHandle) SetRetryPolicy(policy *RetryPolicy) {
+	if policy != nil {
+		copied := *policy
+		if copied.NumberOfRetries < 0 {
+			copied.NumberOfRetries = 0
+		}
+		policy = &copied
+	}
+
+	for _, monitor := range handle.monitors {
+		monitor.retry.Store(policy)
+	}
+}
+
+func (handle *StageHandle) Pause() {
+	for _, monitor := range handle.monitors {
+		monitor.gate.pause()
+	}
+}
+
+func (handle *StageHandle) Resume() {
+	handle.runner.touch()
+	for _, monitor := range handle.monitors {
+		monitor.gate.resume()
+	}
+}
+
+// Current state of every dispatched instance of the stage.
+func (handle *StageHandle) Snapshot() []StageSnapshot {
+	snapshots := make([]StageSnapshot, 0, len(handle.monitors))
+	for _, monitor := range handle.monitors {
+		snapshots = append(snapshots, monitor.snapshot())
+	}
+	return snapshots
+}
@@ -0,0 +1,111 @@
+package conveyor
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStageHandleSetMaxScale(t *testing.T) {
+	for _, execution := range []ExecutionModel{GoroutinePerParcel, WorkerPool} {
+		numIter := 40
+		var inFlight, peak int64
+		release := make(chan struct{})
+		runner := New(nil).
+			AddSource(&Stage{
+				Process: func(parcel *Parcel) interface{} {
+					if parcel.Sequence >= numIter {
+						return Stop
+					}
+					return parcel.Sequence
+				},
+			}).
+			AddSink(&Stage{
+				Name:      "write",
+				MaxScale:  1,
+				Execution: execution,
+				Process: func(parcel *Parcel) interface{} {
+					current := atomic.AddInt64(&inFlight, 1)
+					defer atomic.AddInt64(&inFlight, -1)
+					for {
+						old := atomic.LoadInt64(&peak)
+						if current <= old || atomic.CompareAndSwapInt64(&peak, old, current) {
+							break
+						}
+					}
+					<-release
+					return nil
+				},
+			}).Build().DispatchBackground()
+
+		handle := runner.Stage("write")
+		assert.NotNil(t, handle)
+		assert.Nil(t, runner.Stage("missing"))
+
+		assert.Eventually(t, func() bool { return atomic.LoadInt64(&inFlight) == 1 }, time.Second, time.Millisecond)
+		handle.SetMaxScale(4)
+		assert.Eventually(t, func() bool { return atomic.LoadInt64(&inFlight) == 4 }, time.Second, time.Millisecond)
+		assert.Equal(t, uint(4), handle.Snapshot()[0].MaxScale)
+
+		close(release)
+		runner.Wait()
+		assert.Equal(t, int64(4), atomic.LoadInt64(&peak))
+	}
+}
+
+func TestStageHandleSetRateLimit(t *testing.T) {
+	numIter := 10
+	runner := New(nil).
+		AddSource(&Stage{
+			Name: "generate",
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= numIter {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddSink(&Stage{Name: "write"}).Build().DispatchBackground()
+
+	handle := runner.Stage("generate")
+	handle.Pause()
+	handle.SetRateLimit(200)
+	assert.Equal(t, float64(200), handle.Snapshot()[0].RateLimit)
+
+	ts := time.Now()
+	handle.Resume()
+	runner.Wait()
+	assert.GreaterOrEqual(t, int64(time.Since(ts)), int64(40*time.Millisecond))
+}
+
+func TestStageHandleSetRetryPolicy(t *testing.T) {
+	var attempts int64
+	start := make(chan struct{})
+	handler := newRecordingErrorHandler()
+	runner := New(&Options{ErrorHandler: handler}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				<-start
+				if parcel.Sequence >= 2 {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddSink(&Stage{
+			Name: "write",
+			Process: func(parcel *Parcel) interface{} {
+				atomic.AddInt64(&attempts, 1)
+				panic("failure")
+			},
+		}).Build().DispatchBackground()
+
+	runner.Stage("write").SetRetryPolicy(&RetryPolicy{NumberOfRetries: 0, Policy: Static})
+	close(start)
+	runner.Wait()
+
+	assert.Equal(t, int64(2), atomic.LoadInt64(&attempts))
+	assert.Len(t, handler.handled(), 2)
+}
@@ -0,0 +1,26 @@
+package conveyor
+
+import "sync"
+
+// Error handler recording every error it is handed, shared by the tests asserting on them.
+type recordingErrorHandler struct {
+	mutex *sync.Mutex
+	errs  []*Error
+}
+
+func newRecordingErrorHandler() *recordingErrorHandler {
+	return &recordingErrorHandler{mutex: &sync.Mutex{}}
+}
+
+func (handler *recordingErrorHandler) Handle(stage *Stage, parcel *Parcel, err *Error) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+	handler.errs = append(handler.errs, err)
+}
+
+// Errors handled so far in the order they were handed.
+func (handler *recordingErrorHandler) handled() []*Error {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+	return append([]*Error(nil), handler.errs...)
+}
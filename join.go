@@ -0,0 +1,339 @@
+package conveyor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outputs of every fanout branch for a single parcel, keyed by the branch's stage name.
+type Joined map[string]interface{}
+
+// What a join emits when a branch skipped, failed or did not arrive in time.
+type JoinAction int
+
+const (
+	// Skip and Failure are propagated, a timeout fails the joined parcel
+	JoinDefault JoinAction = iota
+	// Leaves the branch out of the joined parcel
+	JoinOmit
+	JoinSkip
+	JoinFailure
+)
+
+// Configures how a join fanin combines the branch outputs.
+type JoinPolicy struct {
+	// Time to wait for the remaining branches once the first one arrived, waits forever when zero
+	Timeout time.Duration
+	// Time the parcels arriving after a timeout are still dropped, later ones are joined anew.
+	// Defaults to ten Timeouts
+	LateWindow time.Duration
+	OnSkip     JoinAction
+	OnFailure  JoinAction
+	OnTimeout  JoinAction
+}
+
+type join struct {
+	policy   JoinPolicy
+	branches []string
+}
+
+type joinEntry struct {
+	key      string
+	parcel   *Parcel
+	joined   Joined
+	arrived  []bool
+	count    int
+	action   JoinAction
+	deadline time.Time
+}
+
+// Timeouts making up the default JoinPolicy.LateWindow.
+const joinLateTimeouts = 10
+
+// Branches still expected for an entry emitted on timeout, forgotten after the late window.
+type joinLate struct {
+	key       string
+	remaining int
+	deadline  time.Time
+}
+
+type joinArrival struct {
+	branch int
+	parcel *Parcel
+}
+
+func newJoin(policy *JoinPolicy, branches []string) *join {
+	join := &join{branches: branches}
+	if policy != nil {
+		join.policy = *policy
+	}
+	if join.policy.OnSkip == JoinDefault {
+		join.policy.OnSkip = JoinSkip
+	}
+	if join.policy.OnFailure == JoinDefault {
+		join.policy.OnFailure = JoinFailure
+	}
+	if join.policy.OnTimeout == JoinDefault {
+		join.policy.OnTimeout = JoinFailure
+	}
+	if join.policy.LateWindow == 0 {
+		join.policy.LateWindow = joinLateTimeouts * join.policy.Timeout
+	}
+	return join
+}
+
+func (builder *builder) Join(stage *Stage, policy *JoinPolicy) IStage {
+	fanout := len(builder.stages) - 1
+	width := len(builder.stages[fanout])
+	for fanout > 0 && len(builder.stages[fanout-1]) == width {
+		fanout--
+	}
+
+	branches := make([]string, 0, width)
+	seen := make(map[string]bool)
+	for _, branch := range builder.stages[fanout] {
+		if seen[branch.Name] {
+			panic(fmt.Sprintf("join requires unique branch names, '%s' is used by multiple branches", branch.Name))
+		}
+		seen[branch.Name] = true
+		branches = append(branches, branch.Name)
+	}
+
+	if policy != nil && policy.Timeout < 0 {
+		panic(fmt.Sprintf("join timeout '%s' must not be negative", policy.Timeout))
+	}
+	if policy != nil && policy.LateWindow < 0 {
+		panic(fmt.Sprintf("join late window '%s' must not be negative", policy.LateWindow))
+	}
+
+	builder.Fanin(stage)
+	stage.join = newJoin(policy, branches)
+	return builder
+}
+
+// Correlates the parcels of all branches on their lineage without the fanout's branch. Children of
+// splits inside the branches keep their hops and are joined by their position.
+func joinKey(parcel *Parcel) string {
+	return ParcelID{Sequence: parcel.Sequence, Hops: joinHops(parcel.hops)}.String()
+}
+
+// Lineage before entering the fanout followed by the splits within the branch.
+func joinHops(hops []Hop) []Hop {
+	for i, hop := range hops {
+		if !hop.Branch {
+			continue
+		}
+		joined := make([]Hop, i, len(hops)-1)
+		copy(joined, hops[:i])
+		for _, inner := range hops[i+1:] {
+			if !inner.Branch {
+				joined = append(joined, inner)
+			}
+		}
+		return joined
+	}
+	return hops
+}
+
+// Waits until every branch produced its output for a parcel and emits a single parcel holding a Joined.
+func newJoinConnector(ctx context.Context, wg *sync.WaitGroup, join *join, stage *Stage, service *Service, receiver chan *Parcel, senders ...chan *Parcel) {
+	arrivals := make(chan joinArrival)
+	innerWg := &sync.WaitGroup{}
+	for j, sender := range senders {
+		wg.Add(1)
+		innerWg.Add(1)
+		go func(branch int, sender chan *Parcel) {
+			defer wg.Done()
+			defer innerWg.Done()
+			for parcel := range sender {
+				select {
+				case arrivals <- joinArrival{branch: branch, parcel: parcel}:
+				case <-ctx.Done():
+				}
+			}
+		}(j, sender)
+	}
+
+	go func() {
+		innerWg.Wait()
+		close(arrivals)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(receiver)
+
+		pending := make(map[string]*joinEntry)
+		// Branches still expected for entries emitted on timeout, their late parcels are dropped
+		late := make(map[string]*joinLate)
+		expiries := make([]*joinEntry, 0)
+		lates := make([]*joinLate, 0)
+		timer := time.NewTimer(time.Hour)
+		timer.Stop()
+		defer timer.Stop()
+
+		// Parcels merged into another or dropped are done with for a served item
+		settle := func(parcel *Parcel, count int) {
+			if service != nil && count > 0 {
+				service.track(parcel.Sequence, -count)
+			}
+		}
+
+		emit := func(entry *joinEntry) {
+			delete(pending, entry.key)
+			settle(entry.parcel, entry.count-1)
+			select {
+			case receiver <- join.complete(entry):
+			case <-ctx.Done():
+			}
+		}
+
+		schedule := func() {
+			for len(expiries) > 0 && pending[expiries[0].key] != expiries[0] {
+				expiries = expiries[1:]
+			}
+			for len(lates) > 0 && late[lates[0].key] != lates[0] {
+				lates = lates[1:]
+			}
+			timer.Stop()
+			if len(expiries) > 0 && (len(lates) == 0 || expiries[0].deadline.Before(lates[0].deadline)) {
+				timer.Reset(time.Until(expiries[0].deadline))
+			} else if len(lates) > 0 {
+				timer.Reset(time.Until(lates[0].deadline))
+			}
+		}
+
+		for {
+			select {
+			case arrival, ok := <-arrivals:
+				if !ok {
+					for _, entry := range pending {
+						entry.expire(join)
+						emit(entry)
+					}
+					return
+				}
+
+				key := joinKey(arrival.parcel)
+				if entry, ok := late[key]; ok {
+					stage.logger.EnqueueWarning(stage, arrival.parcel, fmt.Sprintf("join received parcel from branch '%s' for '%s' after timing out, dropping it", join.branches[arrival.branch], key))
+					entry.remaining--
+					if entry.remaining <= 0 {
+						delete(late, key)
+					}
+					settle(arrival.parcel, 1)
+					continue
+				}
+
+				entry, ok := pending[key]
+				if !ok {
+					entry = &joinEntry{
+						key:     key,
+						parcel:  arrival.parcel,
+						joined:  make(Joined, len(join.branches)),
+						arrived: make([]bool, len(join.branches)),
+					}
+					pending[key] = entry
+					if join.policy.Timeout > 0 {
+						entry.deadline = time.Now().Add(join.policy.Timeout)
+						expiries = append(expiries, entry)
+						if len(expiries) == 1 {
+							schedule()
+						}
+					}
+				}
+
+				if entry.arrived[arrival.branch] {
+					stage.logger.EnqueueWarning(stage, arrival.parcel, fmt.Sprintf("join received a second parcel from branch '%s' for '%s', dropping it", join.branches[arrival.branch], key))
+					settle(arrival.parcel, 1)
+					continue
+				}
+				entry.receive(join, arrival)
+				if entry.count == len(join.branches) {
+					emit(entry)
+					schedule()
+				}
+			case <-timer.C:
+				now := time.Now()
+				for len(expiries) > 0 && !expiries[0].deadline.After(now) {
+					entry := expiries[0]
+					expiries = expiries[1:]
+					if pending[entry.key] == entry {
+						entry.expire(join)
+						missing := entry.missing(join)
+						stage.logger.EnqueueWarning(stage, entry.parcel, fmt.Sprintf("join timed out waiting for '%s' on branch(es) '%s'", entry.key, strings.Join(missing, ", ")))
+						remaining := &joinLate{key: entry.key, remaining: len(missing), deadline: now.Add(join.policy.LateWindow)}
+						late[entry.key] = remaining
+						lates = append(lates, remaining)
+						emit(entry)
+					}
+				}
+				for len(lates) > 0 && !lates[0].deadline.After(now) {
+					if late[lates[0].key] == lates[0] {
+						delete(late, lates[0].key)
+					}
+					lates = lates[1:]
+				}
+				schedule()
+			case <-ctx.Done():
+				for range arrivals {
+				}
+				return
+			}
+		}
+	}()
+}
+
+func (entry *joinEntry) receive(join *join, arrival joinArrival) {
+	entry.arrived[arrival.branch] = true
+	entry.count++
+
+	name := join.branches[arrival.branch]
+	switch arrival.parcel.Content {
+	case Skip:
+		entry.worsen(join.policy.OnSkip)
+	case Failure:
+		entry.worsen(join.policy.OnFailure)
+	default:
+		entry.joined[name] = arrival.parcel.Content
+	}
+}
+
+func (entry *joinEntry) expire(join *join) {
+	entry.worsen(join.policy.OnTimeout)
+}
+
+// Failure outweighs Skip, which outweighs omitting the branch.
+func (entry *joinEntry) worsen(action JoinAction) {
+	if action > entry.action {
+		entry.action = action
+	}
+}
+
+func (entry *joinEntry) missing(join *join) []string {
+	missing := make([]string, 0)
+	for j, arrived := range entry.arrived {
+		if !arrived {
+			missing = append(missing, join.branches[j])
+		}
+	}
+	return missing
+}
+
+func (join *join) complete(entry *joinEntry) *Parcel {
+	var content interface{} = entry.joined
+	switch entry.action {
+	case JoinSkip:
+		content = Skip
+	case JoinFailure:
+		content = Failure
+	}
+
+	parcel := entry.parcel.pack(content)
+	parcel.hops = joinHops(parcel.hops)
+	return parcel
+}
@@ -0,0 +1,154 @@
+package conveyor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newJoinSource(numIter int) *Stage {
+	return &Stage{
+		Process: func(parcel *Parcel) interface{} {
+			if parcel.Sequence >= numIter {
+				return Stop
+			}
+			return parcel.Sequence
+		},
+	}
+}
+
+func TestJoinMergesBranchesPerSequence(t *testing.T) {
+	numIter := 20
+	runner := New(&Options{CollectResults: true}).
+		AddSource(newJoinSource(numIter)).
+		Fanout(
+			&Stage{Name: "double", MaxScale: 4, Process: func(parcel *Parcel) interface{} { return parcel.Content.(int) * 2 }},
+			&Stage{Name: "increment", Process: func(parcel *Parcel) interface{} { return parcel.Content.(int) + 1 }},
+		).
+		AddStages(&Stage{Name: "left"}, &Stage{Name: "right"}).
+		Join(&Stage{}, nil).
+		AddSink(&Stage{}).Build().DispatchWithTimeout(time.Second)
+
+	results := Collect(runner, true)
+	assert.Len(t, results, numIter)
+	for i, result := range results {
+		assert.Equal(t, i, result.Sequence)
+		assert.Equal(t, Joined{"double": i * 2, "increment": i + 1}, result.Content)
+		assert.Empty(t, result.ID.Hops)
+	}
+}
+
+func TestJoinSkipAndFailurePolicies(t *testing.T) {
+	build := func(policy *JoinPolicy) []Result {
+		runner := New(&Options{CollectResults: true}).
+			AddSource(newJoinSource(3)).
+			Fanout(
+				&Stage{Name: "left"},
+				&Stage{Name: "right", Process: func(parcel *Parcel) interface{} {
+					switch parcel.Sequence {
+					case 1:
+						return Skip
+					case 2:
+						panic("failure")
+					}
+					return parcel.Content
+				}},
+			).
+			Join(&Stage{}, policy).
+			AddSink(&Stage{}).Build().DispatchWithTimeout(time.Second)
+		return Collect(runner, true)
+	}
+
+	results := build(nil)
+	assert.Equal(t, Joined{"left": 0, "right": 0}, results[0].Content)
+	assert.ErrorIs(t, results[1].Err, ErrSkipped)
+	assert.ErrorIs(t, results[2].Err, ErrFailure)
+
+	results = build(&JoinPolicy{OnSkip: JoinOmit, OnFailure: JoinSkip})
+	assert.Equal(t, Joined{"left": 1}, results[1].Content)
+	assert.ErrorIs(t, results[2].Err, ErrSkipped)
+}
+
+func TestJoinTimeout(t *testing.T) {
+	runner := New(&Options{CollectResults: true}).
+		AddSource(newJoinSource(2)).
+		Fanout(
+			&Stage{Name: "fast"},
+			&Stage{Name: "slow", MaxScale: 2, Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence == 0 {
+					time.Sleep(100 * time.Millisecond)
+				}
+				return parcel.Content
+			}},
+		).
+		Join(&Stage{}, &JoinPolicy{Timeout: 20 * time.Millisecond, OnTimeout: JoinOmit}).
+		AddSink(&Stage{}).Build().DispatchWithTimeout(time.Second)
+
+	results := Collect(runner, true)
+	assert.Len(t, results, 2)
+	assert.Equal(t, Joined{"fast": 0}, results[0].Content)
+	assert.Equal(t, Joined{"fast": 1, "slow": 1}, results[1].Content)
+}
+
+func TestJoinForgetsTimedOutParcels(t *testing.T) {
+	runner := New(&Options{CollectResults: true}).
+		AddSource(newJoinSource(2)).
+		Fanout(
+			&Stage{Name: "fast"},
+			&Stage{Name: "slow", MaxScale: 2, Process: func(parcel *Parcel) interface{} {
+				// Within the late window of sequence 0, past the one of sequence 1
+				time.Sleep(time.Duration(30+parcel.Sequence*270) * time.Millisecond)
+				return parcel.Content
+			}},
+		).
+		Join(&Stage{}, &JoinPolicy{Timeout: 10 * time.Millisecond, LateWindow: 100 * time.Millisecond, OnTimeout: JoinOmit}).
+		AddSink(&Stage{}).Build().DispatchWithTimeout(time.Second)
+
+	contents := make([]interface{}, 0)
+	for _, result := range Collect(runner, true) {
+		contents = append(contents, result.Content)
+	}
+	assert.ElementsMatch(t, []interface{}{Joined{"fast": 0}, Joined{"fast": 1}, Joined{"slow": 1}}, contents)
+}
+
+func TestJoinCorrelatesSplitsWithinBranches(t *testing.T) {
+	split := func(name string, factor int) *Stage {
+		return &Stage{Name: name, Process: func(parcel *Parcel) interface{} {
+			return UnpackData([]int{parcel.Content.(int) + 1, (parcel.Content.(int) + 1) * factor})
+		}}
+	}
+
+	run := func(right *Stage) []Result {
+		runner := New(&Options{CollectResults: true}).
+			AddSource(newJoinSource(1)).
+			Fanout(split("left", 10), right).
+			Join(&Stage{}, &JoinPolicy{OnTimeout: JoinOmit}).
+			AddSink(&Stage{}).Build().DispatchWithTimeout(time.Second)
+		return Collect(runner, false)
+	}
+
+	ids, contents := make([]string, 0), make([]interface{}, 0)
+	for _, result := range run(split("right", 100)) {
+		ids = append(ids, result.ID.String())
+		contents = append(contents, result.Content)
+	}
+	assert.ElementsMatch(t, []string{"0.0", "0.1"}, ids)
+	assert.ElementsMatch(t, []interface{}{Joined{"left": 1, "right": 1}, Joined{"left": 10, "right": 100}}, contents)
+
+	// Children without a counterpart are emitted on their own instead of being dropped
+	contents = make([]interface{}, 0)
+	for _, result := range run(&Stage{Name: "right"}) {
+		contents = append(contents, result.Content)
+	}
+	assert.ElementsMatch(t, []interface{}{Joined{"left": 1}, Joined{"left": 10}, Joined{"right": 0}}, contents)
+}
+
+func TestJoinRequiresUniqueBranchNames(t *testing.T) {
+	assert.Panics(t, func() {
+		New(nil).
+			AddSource(newJoinSource(1)).
+			Fanout(&Stage{}, &Stage{}).
+			Join(&Stage{}, nil)
+	})
+}
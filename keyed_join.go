@@ -0,0 +1,234 @@
+package conveyor
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+type JoinMode int
+
+const (
+	// Emits only matched pairs
+	InnerJoin JoinMode = iota
+	// Also emits left items which expired unmatched, with a nil Right
+	LeftJoin
+)
+
+// Joins the items of two sources on a key, see ISource.JoinSources.
+type KeyedJoin struct {
+	// Keys must be comparable
+	LeftKey  func(content interface{}) interface{}
+	RightKey func(content interface{}) interface{}
+	// Time an item waits for matches of the other source, kept until both sources are done when zero
+	TTL  time.Duration
+	Mode JoinMode
+}
+
+// Content emitted by a keyed join for every matching left and right item.
+type JoinedPair struct {
+	Key   interface{}
+	Left  interface{}
+	Right interface{}
+}
+
+const (
+	leftSide  = 0
+	rightSide = 1
+)
+
+type keyedItem struct {
+	side     int
+	key      interface{}
+	parcel   *Parcel
+	matched  bool
+	expired  bool
+	deadline time.Time
+}
+
+// Unexpired items of both sides sharing a key.
+type keyedState struct {
+	items [2][]*keyedItem
+}
+
+func (builder *builder) JoinSources(left, right, stage *Stage, join *KeyedJoin) IStage {
+	if join == nil || join.LeftKey == nil || join.RightKey == nil {
+		panic("keyed join requires both a left and a right key function")
+	}
+	if join.TTL < 0 {
+		panic(fmt.Sprintf("keyed join ttl '%s' must not be negative", join.TTL))
+	}
+
+	builder.mutex.Lock()
+	builder.verifyInput(left, right)
+	builder.stages = append(builder.stages, []*Stage{left, right})
+	builder.mutex.Unlock()
+
+	builder.Fanin(stage)
+	copied := *join
+	stage.keyedJoin = &copied
+	return builder
+}
+
+// Matches the parcels of the left and right source on their keys and emits a JoinedPair
+// parcel with a fresh sequence for every match.
+func newKeyedJoinConnector(ctx context.Context, wg *sync.WaitGroup, join *KeyedJoin, stage *Stage, receiver chan *Parcel, left, right chan *Parcel) {
+	arrivals := make(chan *keyedItem)
+	innerWg := &sync.WaitGroup{}
+	for side, sender := range []chan *Parcel{left, right} {
+		wg.Add(1)
+		innerWg.Add(1)
+		go func(side int, sender chan *Parcel) {
+			defer wg.Done()
+			defer innerWg.Done()
+			for parcel := range sender {
+				select {
+				case arrivals <- &keyedItem{side: side, parcel: parcel}:
+				case <-ctx.Done():
+				}
+			}
+		}(side, sender)
+	}
+
+	go func() {
+		innerWg.Wait()
+		close(arrivals)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(receiver)
+
+		states := make(map[interface{}]*keyedState)
+		expiries := make([]*keyedItem, 0)
+		sequence := 0
+		timer := time.NewTimer(time.Hour)
+		timer.Stop()
+		defer timer.Stop()
+
+		emit := func(from *Parcel, pair JoinedPair) {
+			parcel := from.pack(pair)
+			parcel.Sequence = sequence
+			parcel.hops = nil
+			sequence++
+			select {
+			case receiver <- parcel:
+			case <-ctx.Done():
+			}
+		}
+
+		expire := func(item *keyedItem) {
+			item.expired = true
+			state := states[item.key]
+			items := state.items[item.side]
+			for i := range items {
+				if items[i] == item {
+					state.items[item.side] = append(items[:i:i], items[i+1:]...)
+					break
+				}
+			}
+			if len(state.items[leftSide]) == 0 && len(state.items[rightSide]) == 0 {
+				delete(states, item.key)
+			}
+
+			if item.side == leftSide && !item.matched && join.Mode == LeftJoin {
+				emit(item.parcel, JoinedPair{Key: item.key, Left: item.parcel.Content})
+			}
+		}
+
+		// A panicking key function or an unhashable key is handed to the error handler and the item dropped.
+		lookup := func(item *keyedItem) (state *keyedState, ok bool) {
+			defer func() {
+				if err := recover(); err != nil {
+					stage.ErrorHandler.Handle(stage, item.parcel, &Error{
+						Data:  fmt.Errorf("keyed join failed to key parcel '%d', dropping it: %v", item.parcel.Sequence, err),
+						Stack: string(debug.Stack()),
+					})
+					state, ok = nil, false
+				}
+			}()
+
+			keyOf := join.LeftKey
+			if item.side == rightSide {
+				keyOf = join.RightKey
+			}
+			item.key = keyOf(item.parcel.Content)
+
+			state, found := states[item.key]
+			if !found {
+				state = &keyedState{}
+				states[item.key] = state
+			}
+			return state, true
+		}
+
+		schedule := func() {
+			timer.Stop()
+			if len(expiries) > 0 {
+				timer.Reset(time.Until(expiries[0].deadline))
+			}
+		}
+
+		for {
+			select {
+			case item, ok := <-arrivals:
+				if !ok {
+					for _, item := range expiries {
+						expire(item)
+					}
+					for _, state := range states {
+						for _, item := range state.items[leftSide] {
+							if !item.expired {
+								expire(item)
+							}
+						}
+					}
+					return
+				}
+
+				if _, ok := item.parcel.Content.(Signal); ok {
+					stage.logger.EnqueueDebug(stage, item.parcel, fmt.Sprintf("keyed join received a skipped or failed parcel '%d', dropping it", item.parcel.Sequence))
+					continue
+				}
+
+				state, ok := lookup(item)
+				if !ok {
+					continue
+				}
+
+				for _, other := range state.items[1-item.side] {
+					other.matched = true
+					item.matched = true
+					if item.side == leftSide {
+						emit(item.parcel, JoinedPair{Key: item.key, Left: item.parcel.Content, Right: other.parcel.Content})
+					} else {
+						emit(other.parcel, JoinedPair{Key: item.key, Left: other.parcel.Content, Right: item.parcel.Content})
+					}
+				}
+				state.items[item.side] = append(state.items[item.side], item)
+
+				if join.TTL > 0 {
+					item.deadline = time.Now().Add(join.TTL)
+					expiries = append(expiries, item)
+					if len(expiries) == 1 {
+						schedule()
+					}
+				}
+			case <-timer.C:
+				now := time.Now()
+				for len(expiries) > 0 && !expiries[0].deadline.After(now) {
+					expire(expiries[0])
+					expiries = expiries[1:]
+				}
+				schedule()
+			case <-ctx.Done():
+				for range arrivals {
+				}
+				return
+			}
+		}
+	}()
+}
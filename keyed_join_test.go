@@ -0,0 +1,144 @@
+package conveyor
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testOrder struct {
+	ID int
+}
+
+type testPayment struct {
+	OrderID int
+}
+
+func newKeyedJoinFactory(mode JoinMode, ttl, delay time.Duration, orders int, payments ...int) IFactory {
+	return New(&Options{CollectResults: true}).
+		JoinSources(
+			&Stage{
+				Name: "orders",
+				Process: func(parcel *Parcel) interface{} {
+					if parcel.Sequence >= orders {
+						return Stop
+					}
+					return testOrder{ID: parcel.Sequence}
+				},
+			},
+			&Stage{
+				Name: "payments",
+				Process: func(parcel *Parcel) interface{} {
+					if parcel.Sequence >= len(payments) {
+						return Stop
+					}
+					time.Sleep(delay)
+					return testPayment{OrderID: payments[parcel.Sequence]}
+				},
+			},
+			&Stage{Name: "enrich"},
+			&KeyedJoin{
+				LeftKey:  func(content interface{}) interface{} { return content.(testOrder).ID },
+				RightKey: func(content interface{}) interface{} { return content.(testPayment).OrderID },
+				TTL:      ttl,
+				Mode:     mode,
+			},
+		).
+		AddSink(&Stage{}).Build()
+}
+
+func collectPairs(t *testing.T, runner *Runner) []JoinedPair {
+	pairs := make([]JoinedPair, 0)
+	sequences := make([]int, 0)
+	for _, result := range Collect(runner, true) {
+		pairs = append(pairs, result.Content.(JoinedPair))
+		sequences = append(sequences, result.Sequence)
+	}
+	for i, sequence := range sequences {
+		assert.Equal(t, i, sequence)
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key.(int) < pairs[j].Key.(int) })
+	return pairs
+}
+
+func TestKeyedInnerJoin(t *testing.T) {
+	runner := newKeyedJoinFactory(InnerJoin, 0, 0, 5, 4, 0, 2, 9).DispatchWithTimeout(time.Second)
+
+	assert.Equal(t, []JoinedPair{
+		{Key: 0, Left: testOrder{ID: 0}, Right: testPayment{OrderID: 0}},
+		{Key: 2, Left: testOrder{ID: 2}, Right: testPayment{OrderID: 2}},
+		{Key: 4, Left: testOrder{ID: 4}, Right: testPayment{OrderID: 4}},
+	}, collectPairs(t, runner))
+}
+
+func TestKeyedLeftJoinEmitsUnmatched(t *testing.T) {
+	runner := newKeyedJoinFactory(LeftJoin, 0, 0, 3, 1).DispatchWithTimeout(time.Second)
+
+	assert.Equal(t, []JoinedPair{
+		{Key: 0, Left: testOrder{ID: 0}},
+		{Key: 1, Left: testOrder{ID: 1}, Right: testPayment{OrderID: 1}},
+		{Key: 2, Left: testOrder{ID: 2}},
+	}, collectPairs(t, runner))
+}
+
+func TestKeyedJoinExpiresState(t *testing.T) {
+	runner := newKeyedJoinFactory(InnerJoin, 20*time.Millisecond, 100*time.Millisecond, 1, 0).DispatchWithTimeout(time.Second)
+	assert.Empty(t, collectPairs(t, runner))
+
+	runner = newKeyedJoinFactory(LeftJoin, 20*time.Millisecond, 100*time.Millisecond, 1, 0).DispatchWithTimeout(time.Second)
+	assert.Equal(t, []JoinedPair{{Key: 0, Left: testOrder{ID: 0}}}, collectPairs(t, runner))
+}
+
+func TestKeyedJoinRequiresKeys(t *testing.T) {
+	assert.Panics(t, func() {
+		New(nil).JoinSources(&Stage{}, &Stage{}, &Stage{}, &KeyedJoin{})
+	})
+}
+
+func TestKeyedJoinDropsUnhashableKeys(t *testing.T) {
+	handler := newRecordingErrorHandler()
+	runner := New(&Options{CollectResults: true, ErrorHandler: handler}).
+		JoinSources(
+			&Stage{
+				Name: "orders",
+				Process: func(parcel *Parcel) interface{} {
+					if parcel.Sequence >= 3 {
+						return Stop
+					}
+					return testOrder{ID: parcel.Sequence}
+				},
+			},
+			&Stage{
+				Name: "payments",
+				Process: func(parcel *Parcel) interface{} {
+					if parcel.Sequence >= 3 {
+						return Stop
+					}
+					return testPayment{OrderID: parcel.Sequence}
+				},
+			},
+			&Stage{Name: "enrich"},
+			&KeyedJoin{
+				LeftKey: func(content interface{}) interface{} {
+					if content.(testOrder).ID == 1 {
+						return []int{1}
+					}
+					return content.(testOrder).ID
+				},
+				RightKey: func(content interface{}) interface{} {
+					if content.(testPayment).OrderID == 2 {
+						panic("no order id")
+					}
+					return content.(testPayment).OrderID
+				},
+			},
+		).
+		AddSink(&Stage{}).Build().DispatchWithTimeout(time.Second)
+
+	assert.Equal(t, []JoinedPair{
+		{Key: 0, Left: testOrder{ID: 0}, Right: testPayment{OrderID: 0}},
+	}, collectPairs(t, runner))
+	assert.Len(t, handler.handled(), 2)
+}
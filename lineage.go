@@ -0,0 +1,65 @@
+package conveyor
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Hierarchical identity of a parcel, the source item's sequence followed by every
+// Unpack split and fanout branch the parcel went through.
+type ParcelID struct {
+	Sequence int
+	Hops     []Hop
+}
+
+// Single step in the lineage of a parcel.
+type Hop struct {
+	// Stage which split the parcel or the first stage of the entered branch
+	Stage string
+	// Entered a fanout branch, otherwise a child of an Unpack
+	Branch bool
+	// Index of the branch or of the child within the Unpack
+	Index int
+}
+
+// Formats the id as the sequence followed by '/<branch>' and '.<child>' hops, e.g. '3/left.1'.
+func (id ParcelID) String() string {
+	builder := &strings.Builder{}
+	builder.WriteString(strconv.Itoa(id.Sequence))
+	for _, hop := range id.Hops {
+		if hop.Branch {
+			builder.WriteString("/")
+			builder.WriteString(hop.Stage)
+		} else {
+			builder.WriteString(".")
+			builder.WriteString(strconv.Itoa(hop.Index))
+		}
+	}
+	return builder.String()
+}
+
+// Fanout branches the parcel went through, outermost first.
+func (id ParcelID) Branches() []string {
+	branches := make([]string, 0)
+	for _, hop := range id.Hops {
+		if hop.Branch {
+			branches = append(branches, hop.Stage)
+		}
+	}
+	return branches
+}
+
+// Identity of the parcel within the conveyor, see ParcelID.
+func (parcel *Parcel) ID() ParcelID {
+	return ParcelID{
+		Sequence: parcel.Sequence,
+		Hops:     parcel.hops,
+	}
+}
+
+// Hops are shared between parcels, appending always copies.
+func appendHop(hops []Hop, hop Hop) []Hop {
+	appended := make([]Hop, len(hops), len(hops)+1)
+	copy(appended, hops)
+	return append(appended, hop)
+}
@@ -0,0 +1,52 @@
+package conveyor
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParcelIDString(t *testing.T) {
+	id := ParcelID{Sequence: 3, Hops: []Hop{
+		{Stage: "left", Branch: true},
+		{Stage: "split", Index: 1},
+	}}
+
+	assert.Equal(t, "3/left.1", id.String())
+	assert.Equal(t, []string{"left"}, id.Branches())
+	assert.Equal(t, "7", ParcelID{Sequence: 7}.String())
+}
+
+func TestLineageAcrossUnpackAndFanout(t *testing.T) {
+	runner := New(&Options{CollectResults: true}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= 2 {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddStage(&Stage{
+			Name: "split",
+			Process: func(parcel *Parcel) interface{} {
+				return Unpack{Data: []interface{}{"a", "b"}}
+			},
+		}).
+		Fanout(&Stage{Name: "left"}, &Stage{Name: "right"}).
+		Fanin(&Stage{}).
+		AddSink(&Stage{}).Build().DispatchBackground()
+
+	ids := make([]string, 0)
+	for _, result := range Collect(runner, false) {
+		assert.Equal(t, result.Sequence, result.ID.Sequence)
+		ids = append(ids, result.ID.String())
+	}
+	sort.Strings(ids)
+
+	assert.Equal(t, []string{
+		"0.0/left", "0.0/right", "0.1/left", "0.1/right",
+		"1.0/left", "1.0/right", "1.1/left", "1.1/right",
+	}, ids)
+}
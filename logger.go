@@ -19,7 +19,7 @@ type ILogger interface {
 	EnqueueDebug(stage *Stage, parcel *Parcel, args ...interface{})
 
 	flush(sequence int)
-	flusher(wg *sync.WaitGroup, flushMessageC chan *flushMessage, numSequences int)
+	flusher(wg *sync.WaitGroup, flushMessageC chan flushMessage, numSequences int)
 }
 
 type Logger struct {
@@ -99,46 +99,48 @@ func (logger *Logger) Debug(stage *Stage, args ...interface{}) {
 }
 
 func (logger *Logger) EnqueueWarning(stage *Stage, parcel *Parcel, args ...interface{}) {
+	fields := logger.parcelFields(stage, parcel)
 	logger.Append(parcel, func() {
-		logger.logger.WithFields(logrus.Fields{
-			"conveyor": logger.name,
-			"stage":    stage.Name,
-			"sequence": parcel.Sequence,
-		}).Warning(args...)
+		logger.logger.WithFields(fields).Warning(args...)
 	})
 }
 
 func (logger *Logger) EnqueueError(stage *Stage, parcel *Parcel, args ...interface{}) {
+	fields := logger.parcelFields(stage, parcel)
 	logger.Append(parcel, func() {
-		logger.logger.WithFields(logrus.Fields{
-			"conveyor": logger.name,
-			"stage":    stage.Name,
-			"sequence": parcel.Sequence,
-		}).Error(args...)
+		logger.logger.WithFields(fields).Error(args...)
 	})
 }
 
 func (logger *Logger) EnqueueInformation(stage *Stage, parcel *Parcel, args ...interface{}) {
+	fields := logger.parcelFields(stage, parcel)
 	logger.Append(parcel, func() {
-		logger.logger.WithFields(logrus.Fields{
-			"conveyor": logger.name,
-			"stage":    stage.Name,
-			"sequence": parcel.Sequence,
-		}).Info(args...)
+		logger.logger.WithFields(fields).Info(args...)
 	})
 }
 
 func (logger *Logger) EnqueueDebug(stage *Stage, parcel *Parcel, args ...interface{}) {
+	fields := logger.parcelFields(stage, parcel)
+	fields["content"] = parcel.Content
 	logger.Append(parcel, func() {
-		logger.logger.WithFields(logrus.Fields{
-			"conveyor": logger.name,
-			"stage":    stage.Name,
-			"sequence": parcel.Sequence,
-			"content":  parcel.Content,
-		}).Debug(args...)
+		logger.logger.WithFields(fields).Debug(args...)
 	})
 }
 
+// Captured when enqueued, the parcel may have moved on by the time the sequence is flushed.
+func (logger *Logger) parcelFields(stage *Stage, parcel *Parcel) logrus.Fields {
+	fields := logrus.Fields{
+		"conveyor": logger.name,
+		"stage":    stage.Name,
+		"sequence": parcel.Sequence,
+		"id":       parcel.ID().String(),
+	}
+	if parcel.Meta.Len() > 0 {
+		fields["meta"] = parcel.Meta.Map()
+	}
+	return fields
+}
+
 func (logger *Logger) Append(parcel *Parcel, fn func()) {
 	logger.mutex.Lock()
 	defer logger.mutex.Unlock()
@@ -165,7 +167,7 @@ func (logger *Logger) flush(sequence int) {
 	delete(logger.logs, sequence)
 }
 
-func (logger *Logger) flusher(wg *sync.WaitGroup, flushMessageC chan *flushMessage, numInitSequences int) {
+func (logger *Logger) flusher(wg *sync.WaitGroup, flushMessageC chan flushMessage, numInitSequences int) {
 	defer wg.Done()
 	sequences := make(map[int]int)
 
@@ -0,0 +1,65 @@
+package conveyor
+
+// Immutable key/value metadata carried alongside a parcel's content, e.g. correlation ids or source offsets.
+// With returns a modified copy, parcels sharing metadata never observe each other's changes.
+type Meta struct {
+	values map[string]interface{}
+}
+
+// Metadata holding the given values.
+func NewMeta(values map[string]interface{}) Meta {
+	copied := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+	return Meta{values: copied}
+}
+
+func (meta Meta) Get(key string) (interface{}, bool) {
+	value, ok := meta.values[key]
+	return value, ok
+}
+
+// String value of the key, empty when missing or not a string.
+func (meta Meta) String(key string) string {
+	value, _ := meta.values[key].(string)
+	return value
+}
+
+// Copy of the metadata with the key set to the value.
+func (meta Meta) With(key string, value interface{}) Meta {
+	values := make(map[string]interface{}, len(meta.values)+1)
+	for k, v := range meta.values {
+		values[k] = v
+	}
+	values[key] = value
+	return Meta{values: values}
+}
+
+// Copy of the metadata without the key.
+func (meta Meta) Without(key string) Meta {
+	if _, ok := meta.values[key]; !ok {
+		return meta
+	}
+
+	values := make(map[string]interface{}, len(meta.values))
+	for k, v := range meta.values {
+		if k != key {
+			values[k] = v
+		}
+	}
+	return Meta{values: values}
+}
+
+func (meta Meta) Len() int {
+	return len(meta.values)
+}
+
+// Copy of the metadata as a map.
+func (meta Meta) Map() map[string]interface{} {
+	values := make(map[string]interface{}, len(meta.values))
+	for key, value := range meta.values {
+		values[key] = value
+	}
+	return values
+}
@@ -0,0 +1,122 @@
+package conveyor
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetaIsImmutable(t *testing.T) {
+	meta := NewMeta(map[string]interface{}{"tenant": "a"})
+	changed := meta.With("tenant", "b").With("offset", 10)
+
+	assert.Equal(t, "a", meta.String("tenant"))
+	assert.Equal(t, 1, meta.Len())
+	assert.Equal(t, "b", changed.String("tenant"))
+	value, ok := changed.Get("offset")
+	assert.True(t, ok)
+	assert.Equal(t, 10, value)
+
+	values := changed.Map()
+	values["tenant"] = "c"
+	assert.Equal(t, "b", changed.String("tenant"))
+	assert.Equal(t, 1, changed.Without("offset").Len())
+
+	var empty Meta
+	_, ok = empty.Get("tenant")
+	assert.False(t, ok)
+	assert.Equal(t, 1, empty.With("tenant", "a").Len())
+}
+
+func TestMetaPropagatesAcrossStages(t *testing.T) {
+	numIter := 5
+	mutex := &sync.Mutex{}
+	received := make(map[string]int)
+	New(nil).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= numIter {
+					return Stop
+				}
+				assert.Equal(t, 0, parcel.Meta.Len())
+				parcel.Meta = parcel.Meta.With("correlation", parcel.Sequence)
+				return parcel.Sequence
+			},
+		}).
+		Fanout(
+			&Stage{
+				Name: "left",
+				Process: func(parcel *Parcel) interface{} {
+					parcel.Meta = parcel.Meta.With("branch", "left")
+					return Unpack{Data: []interface{}{1, 2}}
+				},
+			},
+			&Stage{
+				Name: "right",
+				Process: func(parcel *Parcel) interface{} {
+					parcel.Meta = parcel.Meta.With("branch", "right")
+					return parcel.Content
+				},
+			},
+		).
+		Fanin(&Stage{}).
+		AddSink(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				correlation, _ := parcel.Meta.Get("correlation")
+				assert.Equal(t, parcel.Sequence, correlation)
+				mutex.Lock()
+				defer mutex.Unlock()
+				received[parcel.Meta.String("branch")]++
+				return nil
+			},
+		}).Build().DispatchBackground().Wait()
+
+	assert.Equal(t, map[string]int{"left": 2 * numIter, "right": numIter}, received)
+}
+
+func TestMetaSurvivesStageTimeout(t *testing.T) {
+	runner := New(&Options{CollectResults: true}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= 3 {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddStage(&Stage{
+			Timeout: time.Second,
+			Process: func(parcel *Parcel) interface{} {
+				parcel.Meta = parcel.Meta.With("tenant", "a")
+				parcel.Priority = HighPriority
+				return parcel.Content
+			},
+		}).
+		AddSink(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				return []interface{}{parcel.Meta.String("tenant"), parcel.Priority}
+			},
+		}).Build().DispatchWithTimeout(time.Second)
+
+	results := Collect(runner, false)
+	assert.Len(t, results, 3)
+	for _, result := range results {
+		assert.Equal(t, []interface{}{"a", HighPriority}, result.Content)
+	}
+}
+
+func TestLoggerFieldsIncludeMeta(t *testing.T) {
+	logger := NewLogger("meta").(*Logger)
+	stage := &Stage{Name: "stage"}
+	parcel := &Parcel{Sequence: 3, Meta: Meta{}.With("tenant", "a")}
+
+	fields := logger.parcelFields(stage, parcel)
+	assert.Equal(t, 3, fields["sequence"])
+	assert.Equal(t, map[string]interface{}{"tenant": "a"}, fields["meta"])
+
+	parcel.Meta = Meta{}
+	_, ok := logger.parcelFields(stage, parcel)["meta"]
+	assert.False(t, ok)
+}
@@ -0,0 +1,182 @@
+package conveyor
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Runtime state of a dispatched stage.
+type monitor struct {
+	inFlight       int64
+	processed      uint64
+	lastSequence   int64
+	retries        uint64
+	backingOff     int64
+	lastBackoff    int64
+	lastProgress   int64
+	blockedSending int64
+	initialized    int32
+	disposed       int32
+
+	stage    *Stage
+	level    int
+	index    int
+	inbound  chan *Parcel
+	outbound chan *Parcel
+	gate     *gate
+	// Edges batched by Stage.Batch, replacing the parcel channels
+	inboundBatches  chan []*Parcel
+	outboundBatches chan []*Parcel
+
+	semaphore *semaphore
+	limiter   *limiter
+	retry     atomic.Value
+}
+
+// Point in time view of a dispatched conveyor.
+type Snapshot struct {
+	Name   string          `json:"name"`
+	Paused bool            `json:"paused"`
+	Stages []StageSnapshot `json:"stages"`
+}
+
+type StageSnapshot struct {
+	Name      string          `json:"name"`
+	Level     int             `json:"level"`
+	Index     int             `json:"index"`
+	InFlight  int             `json:"inFlight"`
+	MaxScale  uint            `json:"maxScale"`
+	RateLimit float64         `json:"rateLimit"`
+	Inbound   ChannelSnapshot `json:"inbound"`
+	Outbound  ChannelSnapshot `json:"outbound"`
+	Processed uint64          `json:"processed"`
+	// Highest sequence processed, -1 before the first
+	LastSequence   int           `json:"lastSequence"`
+	Retries        uint64        `json:"retries"`
+	BackingOff     int           `json:"backingOff"`
+	LastBackoff    time.Duration `json:"lastBackoff"`
+	Initialized    bool          `json:"initialized"`
+	Disposed       bool          `json:"disposed"`
+	BlockedSending int           `json:"blockedSending"`
+	LastProgress   time.Time     `json:"lastProgress"`
+	Paused         bool          `json:"paused"`
+}
+
+type ChannelSnapshot struct {
+	Len int `json:"len"`
+	Cap int `json:"cap"`
+}
+
+func newMonitor(stage *Stage, level, index int, inbound, outbound chan *Parcel) *monitor {
+	return &monitor{
+		stage:        stage,
+		level:        level,
+		index:        index,
+		inbound:      inbound,
+		outbound:     outbound,
+		gate:         newGate(),
+		semaphore:    newSemaphore(int(stage.MaxScale)),
+		limiter:      newLimiter(stage.RateLimit),
+		lastSequence: -1,
+		lastProgress: time.Now().UnixNano(),
+	}
+}
+
+func (monitor *monitor) begin() {
+	if monitor != nil {
+		atomic.AddInt64(&monitor.inFlight, 1)
+		atomic.StoreInt64(&monitor.lastProgress, time.Now().UnixNano())
+	}
+}
+
+func (monitor *monitor) end(parcel *Parcel) {
+	if monitor != nil {
+		atomic.AddUint64(&monitor.processed, 1)
+		// Scaled stages finish out of order, only ever raise it
+		for sequence := int64(parcel.Sequence); ; {
+			last := atomic.LoadInt64(&monitor.lastSequence)
+			if sequence <= last || atomic.CompareAndSwapInt64(&monitor.lastSequence, last, sequence) {
+				break
+			}
+		}
+		atomic.AddInt64(&monitor.inFlight, -1)
+		atomic.StoreInt64(&monitor.lastProgress, time.Now().UnixNano())
+	}
+}
+
+func (monitor *monitor) touch() {
+	atomic.StoreInt64(&monitor.lastProgress, time.Now().UnixNano())
+}
+
+func (monitor *monitor) blocked(delta int64) {
+	if monitor != nil {
+		atomic.AddInt64(&monitor.blockedSending, delta)
+	}
+}
+
+func (monitor *monitor) backoff(duration time.Duration) func() {
+	if monitor == nil {
+		return func() {}
+	}
+
+	atomic.AddUint64(&monitor.retries, 1)
+	atomic.StoreInt64(&monitor.lastBackoff, int64(duration))
+	atomic.AddInt64(&monitor.backingOff, 1)
+	return func() {
+		atomic.AddInt64(&monitor.backingOff, -1)
+	}
+}
+
+func (monitor *monitor) init() {
+	atomic.StoreInt32(&monitor.initialized, 1)
+}
+
+func (monitor *monitor) dispose() {
+	atomic.StoreInt32(&monitor.disposed, 1)
+}
+
+func (monitor *monitor) retryPolicy() *RetryPolicy {
+	if monitor == nil {
+		return nil
+	}
+
+	policy, _ := monitor.retry.Load().(*RetryPolicy)
+	return policy
+}
+
+func (monitor *monitor) snapshot() StageSnapshot {
+	return StageSnapshot{
+		Name:           monitor.stage.Name,
+		Level:          monitor.level,
+		Index:          monitor.index,
+		InFlight:       int(atomic.LoadInt64(&monitor.inFlight)),
+		MaxScale:       uint(monitor.semaphore.size()),
+		RateLimit:      monitor.limiter.getRate(),
+		Inbound:        channelSnapshot(monitor.inbound, monitor.inboundBatches),
+		Outbound:       channelSnapshot(monitor.outbound, monitor.outboundBatches),
+		Processed:      atomic.LoadUint64(&monitor.processed),
+		LastSequence:   int(atomic.LoadInt64(&monitor.lastSequence)),
+		Retries:        atomic.LoadUint64(&monitor.retries),
+		BackingOff:     int(atomic.LoadInt64(&monitor.backingOff)),
+		LastBackoff:    time.Duration(atomic.LoadInt64(&monitor.lastBackoff)),
+		Initialized:    atomic.LoadInt32(&monitor.initialized) == 1,
+		Disposed:       atomic.LoadInt32(&monitor.disposed) == 1,
+		BlockedSending: int(atomic.LoadInt64(&monitor.blockedSending)),
+		LastProgress:   time.Unix(0, atomic.LoadInt64(&monitor.lastProgress)),
+		Paused:         monitor.gate.isPaused(),
+	}
+}
+
+// Batched edges count batches rather than parcels.
+func channelSnapshot(channel chan *Parcel, batches chan []*Parcel) ChannelSnapshot {
+	if batches != nil {
+		return ChannelSnapshot{
+			Len: len(batches),
+			Cap: cap(batches),
+		}
+	}
+	return ChannelSnapshot{
+		Len: len(channel),
+		Cap: cap(channel),
+	}
+}
@@ -0,0 +1,102 @@
+package conveyor
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunnerSnapshot(t *testing.T) {
+	numIter := 10
+	release := make(chan struct{})
+	runner := New(&Options{Name: "snapshot"}).
+		AddSource(&Stage{
+			Name: "numerate",
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= numIter {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddStage(&Stage{
+			Name:       "block",
+			MaxScale:   2,
+			BufferSize: 4,
+			Process: func(parcel *Parcel) interface{} {
+				<-release
+				return parcel.Content
+			},
+		}).
+		AddSink(&Stage{Name: "write"}).Build().DispatchBackground()
+
+	assert.Eventually(t, func() bool {
+		stage := runner.Snapshot().Stages[1]
+		return stage.InFlight == 2 && stage.Inbound.Len == 4
+	}, time.Second, time.Millisecond)
+
+	snapshot := runner.Snapshot()
+	assert.Equal(t, "snapshot", snapshot.Name)
+	assert.Equal(t, uint(2), snapshot.Stages[1].MaxScale)
+	assert.Equal(t, 4, snapshot.Stages[1].Inbound.Cap)
+	assert.True(t, snapshot.Stages[1].Initialized)
+	assert.False(t, snapshot.Stages[1].Disposed)
+	assert.Equal(t, -1, snapshot.Stages[2].LastSequence)
+
+	close(release)
+	runner.Wait()
+
+	snapshot = runner.Snapshot()
+	for _, stage := range snapshot.Stages {
+		assert.Equal(t, 0, stage.InFlight)
+		assert.True(t, stage.Disposed)
+	}
+	assert.Equal(t, uint64(numIter), snapshot.Stages[2].Processed)
+	assert.Equal(t, numIter-1, snapshot.Stages[1].LastSequence)
+	assert.Equal(t, numIter-1, snapshot.Stages[2].LastSequence)
+}
+
+func TestRunnerHandlerReportsRetries(t *testing.T) {
+	runner := New(&Options{
+		CircuitBreaker: &CircuitBreaker{
+			Enabled:         true,
+			NumberOfRetries: 3,
+			Policy:          Static,
+			Interval:        time.Millisecond,
+			Rng:             rand.New(rand.NewSource(1)),
+		},
+	}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= 1 {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddSink(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				panic("test")
+			},
+		}).Build().DispatchBackground()
+	runner.Wait()
+
+	recorder := httptest.NewRecorder()
+	runner.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	snapshot := Snapshot{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &snapshot))
+	assert.Len(t, snapshot.Stages, 2)
+	assert.Equal(t, uint64(2), snapshot.Stages[1].Retries)
+	assert.Equal(t, 0, snapshot.Stages[1].BackingOff)
+
+	recorder = httptest.NewRecorder()
+	runner.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
@@ -1,16 +1,26 @@
 package conveyor
 
 import (
+	"context"
 	"sync"
 )
 
-func newMultiplexerConnector[T any](wg *sync.WaitGroup, sender chan T, receivers ...chan T) {
+// Sends every value to all receivers, a non nil clone gives every receiver but the last its own
+// copy. The copies are made before the original is sent, as its receiver may recycle it.
+func newMultiplexerConnector[T any](ctx context.Context, wg *sync.WaitGroup, sender chan T, clone func(T) T, receivers ...chan T) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		for data := range sender {
-			for _, receiver := range receivers {
-				receiver <- data
+			for k, receiver := range receivers {
+				value := data
+				if clone != nil && k < len(receivers)-1 {
+					value = clone(data)
+				}
+				select {
+				case receiver <- value:
+				case <-ctx.Done():
+				}
 			}
 		}
 		for _, receiver := range receivers {
@@ -19,7 +29,7 @@ func newMultiplexerConnector[T any](wg *sync.WaitGroup, sender chan T, receivers
 	}()
 }
 
-func newDemultiplexerConnector[T any](wg *sync.WaitGroup, receiver chan T, senders ...chan T) {
+func newDemultiplexerConnector[T any](ctx context.Context, wg *sync.WaitGroup, receiver chan T, senders ...chan T) {
 	innerWg := &sync.WaitGroup{}
 	for _, sender := range senders {
 		wg.Add(1)
@@ -28,7 +38,10 @@ func newDemultiplexerConnector[T any](wg *sync.WaitGroup, receiver chan T, sende
 			defer wg.Done()
 			defer innerWg.Done()
 			for data := range sender {
-				receiver <- data
+				select {
+				case receiver <- data:
+				case <-ctx.Done():
+				}
 			}
 		}(sender)
 	}
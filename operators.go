@@ -0,0 +1,77 @@
+package conveyor
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Handed to the stage's error handler when a typed stage receives content of another type,
+// the parcel then fails. Typed stages are meant for segments and sinks, a source has no content.
+var ErrUnexpectedContent = errors.New("conveyor: unexpected content type")
+
+// Stage replacing every content with the result of fn.
+func Map[In, Out any](name string, fn func(In) Out) *Stage {
+	stage := &Stage{Name: name}
+	stage.Process = func(parcel *Parcel) interface{} {
+		value, ok := expect[In](stage, parcel)
+		if !ok {
+			return Failure
+		}
+		return fn(value)
+	}
+	return stage
+}
+
+// Stage skipping the parcels whose content does not satisfy the predicate.
+func Filter[T any](name string, predicate func(T) bool) *Stage {
+	stage := &Stage{Name: name}
+	stage.Process = func(parcel *Parcel) interface{} {
+		value, ok := expect[T](stage, parcel)
+		if !ok {
+			return Failure
+		}
+		if !predicate(value) {
+			return Skip
+		}
+		return parcel.Content
+	}
+	return stage
+}
+
+// Stage splitting every content into the children returned by fn, like returning UnpackData.
+func FlatMap[In, Out any](name string, fn func(In) []Out) *Stage {
+	stage := &Stage{Name: name}
+	stage.Process = func(parcel *Parcel) interface{} {
+		value, ok := expect[In](stage, parcel)
+		if !ok {
+			return Failure
+		}
+		return UnpackData(fn(value))
+	}
+	return stage
+}
+
+// Stage calling fn with every content and passing it on unchanged.
+func Tap[T any](name string, fn func(T)) *Stage {
+	stage := &Stage{Name: name}
+	stage.Process = func(parcel *Parcel) interface{} {
+		value, ok := expect[T](stage, parcel)
+		if !ok {
+			return Failure
+		}
+		fn(value)
+		return parcel.Content
+	}
+	return stage
+}
+
+func expect[T any](stage *Stage, parcel *Parcel) (T, bool) {
+	value, ok := parcel.Content.(T)
+	if !ok {
+		stage.ErrorHandler.Handle(stage, parcel, &Error{
+			Data: fmt.Errorf("%w: stage '%s' expected '%s', got '%T'", ErrUnexpectedContent, stage.Name, reflect.TypeOf((*T)(nil)).Elem(), parcel.Content),
+		})
+	}
+	return value, ok
+}
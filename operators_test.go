@@ -0,0 +1,81 @@
+package conveyor
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypedStages(t *testing.T) {
+	tapped := make([]string, 0)
+	runner := New(&Options{CollectResults: true}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= 13 {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddStage(Map("format", strconv.Itoa)).
+		AddStage(Filter("odd", func(value string) bool {
+			return !strings.ContainsAny(value[len(value)-1:], "02468")
+		})).
+		AddStage(FlatMap("digits", func(value string) []string {
+			return strings.Split(value, "")
+		})).
+		AddStage(Compose("tapped", nil,
+			Tap("record", func(digit string) { tapped = append(tapped, digit) }),
+			Map("parse", func(digit string) int {
+				value, _ := strconv.Atoi(digit)
+				return value
+			}),
+		)).
+		AddSink(&Stage{}).Build().DispatchWithTimeout(5 * time.Second)
+
+	contents, skipped := make([]int, 0), 0
+	for _, result := range Collect(runner, false) {
+		if result.Err == ErrSkipped {
+			skipped++
+			continue
+		}
+		contents = append(contents, result.Content.(int))
+	}
+	sort.Ints(contents)
+	sort.Strings(tapped)
+
+	assert.Equal(t, 7, skipped)
+	assert.Equal(t, []int{1, 1, 1, 3, 5, 7, 9}, contents)
+	assert.Equal(t, []string{"1", "1", "1", "3", "5", "7", "9"}, tapped)
+}
+
+func TestTypedStageRejectsUnexpectedContent(t *testing.T) {
+	handler := newRecordingErrorHandler()
+	runner := New(&Options{CollectResults: true, ErrorHandler: handler}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= 1 {
+					return Stop
+				}
+				return "one"
+			},
+		}).
+		AddStage(Map("double", func(value int) int { return value * 2 })).
+		AddSink(&Stage{}).Build().DispatchWithTimeout(5 * time.Second)
+
+	results := Collect(runner, false)
+	assert.Len(t, results, 1)
+	assert.ErrorIs(t, results[0].Err, ErrFailure)
+
+	handled := handler.handled()
+	assert.Len(t, handled, 1)
+	err, ok := handled[0].Data.(error)
+	assert.True(t, ok)
+	assert.True(t, errors.Is(err, ErrUnexpectedContent))
+	assert.EqualError(t, err, "conveyor: unexpected content type: stage 'double' expected 'int', got 'string'")
+}
@@ -6,6 +6,21 @@ type Options struct {
 	CircuitBreaker ICircuitBreaker
 	Logger         ILogger
 	ErrorHandler   IErrorHandler
+
+	// Publishes the sink's results on Runner.Results(), a served conveyor resolves futures instead
+	CollectResults bool
+	// Detects stalled stages, disabled when nil
+	Watchdog *Watchdog
+	// Serves inbound parcels by priority, disabled when nil
+	PriorityLanes *PriorityLanes
+	// Persists the stage caches across runs, keyed by conveyor and stage name which must be unique
+	Storage IStorage
+	// Execution model of stages not choosing their own
+	Execution ExecutionModel
+	// Recycles parcels through a pool instead of allocating one per hop. A parcel is owned by the
+	// stage holding it and recycled once its Process returns, so Process must not retain the
+	// *Parcel or use it from goroutines outliving the call, copy the needed fields instead.
+	PooledParcels bool
 }
 
 func NewDefaultOptions() *Options {
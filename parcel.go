@@ -1,5 +1,10 @@
 package conveyor
 
+import (
+	"context"
+	"sync"
+)
+
 type Signal int
 
 const (
@@ -10,11 +15,29 @@ const (
 
 //
 type Parcel struct {
-	Content  interface{}
-	Cache    *Cache
+	Content interface{}
+	Cache   *Cache
+	// Cache of the worker processing the parcel, only set for stages with WorkerInit or WorkerDispose
+	Worker   *Cache
 	Stage    *Stage
 	Logger   ILogger
 	Sequence int
+	Priority Priority
+	Meta     Meta
+	// Partition of a partitioned source which generated the parcel
+	Partition int
+	// Cursor of the partition, counting the parcels it generated from zero without the gaps of Sequence
+	PartitionSequence int
+
+	ctx     context.Context
+	monitor *monitor
+	hops    []Hop
+	branch  *Hop
+	pooled  bool
+}
+
+var parcelPool = sync.Pool{
+	New: func() interface{} { return &Parcel{} },
 }
 
 func newParcel(content interface{}, stage *Stage) *Parcel {
@@ -27,32 +50,101 @@ func newParcel(content interface{}, stage *Stage) *Parcel {
 	}
 }
 
+// Cancelled when the conveyor is aborted or the stage's timeout expires.
+func (parcel *Parcel) Context() context.Context {
+	if parcel.ctx == nil {
+		return context.Background()
+	}
+	return parcel.ctx
+}
+
 func (p *Parcel) unpack(parcel *Parcel) *Parcel {
-	return &Parcel{
-		Stage:    parcel.Stage,
-		Content:  parcel.Content,
-		Cache:    p.Cache,
-		Sequence: parcel.Sequence,
-		Logger:   parcel.Logger,
+	hops := parcel.hops
+	if p.branch != nil {
+		hops = appendHop(hops, *p.branch)
+	}
+
+	unpacked := p.allocate()
+	*unpacked = Parcel{
+		Stage:             parcel.Stage,
+		Content:           parcel.Content,
+		Cache:             p.Cache,
+		Sequence:          parcel.Sequence,
+		Priority:          parcel.Priority,
+		Meta:              parcel.Meta,
+		Partition:         parcel.Partition,
+		PartitionSequence: parcel.PartitionSequence,
+		Logger:            parcel.Logger,
+		ctx:               p.ctx,
+		monitor:           p.monitor,
+		hops:              hops,
+		branch:            p.branch,
+		pooled:            p.pooled,
 	}
+	return unpacked
 }
 
 func (parcel *Parcel) pack(content interface{}) *Parcel {
-	return &Parcel{
-		Stage:    parcel.Stage,
-		Content:  content,
-		Cache:    nil,
-		Sequence: parcel.Sequence,
-		Logger:   parcel.Logger,
+	packed := parcel.allocate()
+	*packed = Parcel{
+		Stage:             parcel.Stage,
+		Content:           content,
+		Cache:             nil,
+		Sequence:          parcel.Sequence,
+		Priority:          parcel.Priority,
+		Meta:              parcel.Meta,
+		Partition:         parcel.Partition,
+		PartitionSequence: parcel.PartitionSequence,
+		Logger:            parcel.Logger,
+		hops:              parcel.hops,
+		pooled:            parcel.pooled,
 	}
+	return packed
+}
+
+// Packs the index'th child of an Unpack result split by the stage.
+func (parcel *Parcel) packChild(content interface{}, stage *Stage, index int) *Parcel {
+	child := parcel.pack(content)
+	child.hops = appendHop(parcel.hops, Hop{Stage: stage.Name, Index: index})
+	return child
 }
 
 func (parcel *Parcel) generate(content interface{}) *Parcel {
-	return &Parcel{
-		Stage:    parcel.Stage,
-		Cache:    parcel.Cache,
-		Content:  content,
-		Sequence: parcel.Sequence + 1,
-		Logger:   parcel.Logger,
+	generated := parcel.allocate()
+	*generated = Parcel{
+		Stage:             parcel.Stage,
+		Cache:             parcel.Cache,
+		Content:           content,
+		Sequence:          parcel.Sequence + 1,
+		Partition:         parcel.Partition,
+		PartitionSequence: parcel.PartitionSequence + 1,
+		Logger:            parcel.Logger,
+		ctx:               parcel.ctx,
+		monitor:           parcel.monitor,
+		pooled:            parcel.pooled,
+	}
+	return generated
+}
+
+// Copies the parcel for another owner.
+func (parcel *Parcel) clone() *Parcel {
+	cloned := parcel.allocate()
+	*cloned = *parcel
+	return cloned
+}
+
+// Takes a parcel from the pool when the conveyor pools its parcels.
+func (parcel *Parcel) allocate() *Parcel {
+	if parcel.pooled {
+		return parcelPool.Get().(*Parcel)
+	}
+	return &Parcel{}
+}
+
+// Returns the parcel to the pool once its owner is done with it, see Options.PooledParcels.
+func (parcel *Parcel) release() {
+	if parcel.pooled {
+		*parcel = Parcel{}
+		parcelPool.Put(parcel)
 	}
 }
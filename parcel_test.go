@@ -0,0 +1,78 @@
+package conveyor
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pooledConveyor(pooled bool) []string {
+	runner := New(&Options{CollectResults: true, PooledParcels: pooled}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= 50 {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddStage(Compose("split", nil,
+			&Stage{
+				Process: func(parcel *Parcel) interface{} {
+					if parcel.Content.(int)%7 == 0 {
+						return Skip
+					}
+					return UnpackData([]int{parcel.Content.(int), -parcel.Content.(int)})
+				},
+			},
+			&Stage{
+				Process: func(parcel *Parcel) interface{} {
+					return parcel.Content.(int) * 2
+				},
+			},
+		)).
+		Fanout(&Stage{Name: "left", MaxScale: 4}, &Stage{Name: "right", Execution: WorkerPool, MaxScale: 4}).
+		Fanin(&Stage{}).
+		AddSink(&Stage{MaxScale: 4}).Build().DispatchBackground()
+
+	results := make([]string, 0)
+	for _, result := range Collect(runner, false) {
+		if result.Err != nil {
+			results = append(results, fmt.Sprintf("%s=%s", result.ID, result.Err))
+		} else {
+			results = append(results, fmt.Sprintf("%s=%d", result.ID, result.Content))
+		}
+	}
+	sort.Strings(results)
+	return results
+}
+
+func TestPooledParcelsMatchUnpooled(t *testing.T) {
+	expected := pooledConveyor(false)
+
+	assert.Len(t, expected, 50*2*2-8*2*2+8*2)
+	assert.Equal(t, expected, pooledConveyor(true))
+}
+
+func TestReleasedParcelIsReset(t *testing.T) {
+	parcel := &Parcel{Content: 1, Sequence: 2, pooled: true}
+	packed := parcel.pack(3)
+	parcel.release()
+
+	assert.Equal(t, Parcel{}, *parcel)
+	assert.Equal(t, 3, packed.Content)
+	assert.True(t, packed.pooled)
+
+	unpooled := &Parcel{Content: 1}
+	unpooled.release()
+	assert.Equal(t, 1, unpooled.Content)
+}
+
+func TestUnpackDataKeepsInterfaceSlices(t *testing.T) {
+	data := []interface{}{1, "a"}
+
+	assert.Same(t, &data[0], &UnpackData(data).Data[0])
+	assert.Equal(t, []interface{}{1, 2}, UnpackData([]int{1, 2}).Data)
+}
@@ -0,0 +1,53 @@
+package conveyor
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitionedSource(t *testing.T) {
+	numPartitions, pages := 4, 5
+	var inFlight, peak int64
+	runner := New(&Options{CollectResults: true}).
+		AddSource(&Stage{
+			Partitions: numPartitions,
+			MaxScale:   2,
+			Process: func(parcel *Parcel) interface{} {
+				current := atomic.AddInt64(&inFlight, 1)
+				defer atomic.AddInt64(&inFlight, -1)
+				for {
+					old := atomic.LoadInt64(&peak)
+					if current <= old || atomic.CompareAndSwapInt64(&peak, old, current) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+
+				if parcel.PartitionSequence >= pages {
+					return Stop
+				}
+				return [2]int{parcel.Partition, parcel.PartitionSequence}
+			},
+		}).
+		AddSink(&Stage{}).Build().DispatchWithTimeout(time.Second)
+
+	sequences := make(map[int]bool)
+	read := make(map[[2]int]bool)
+	for _, result := range Collect(runner, false) {
+		assert.False(t, sequences[result.Sequence])
+		sequences[result.Sequence] = true
+		read[result.Content.([2]int)] = true
+	}
+
+	for partition := 0; partition < numPartitions; partition++ {
+		for page := 0; page < pages; page++ {
+			assert.True(t, read[[2]int{partition, page}])
+		}
+	}
+	assert.Len(t, read, numPartitions*pages)
+	assert.Len(t, sequences, numPartitions*pages)
+	assert.Equal(t, int64(2), atomic.LoadInt64(&peak))
+}
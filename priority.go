@@ -0,0 +1,160 @@
+package conveyor
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Urgency of a parcel, stages with priority lanes take higher priorities first.
+type Priority int
+
+const (
+	LowPriority    Priority = -1
+	NormalPriority Priority = 0
+	HighPriority   Priority = 1
+)
+
+const (
+	DefaultStarvationLimit = 8
+	DefaultLaneCapacity    = 64
+)
+
+// Queues the inbound parcels of every stage per priority, dispatching the highest priority first.
+type PriorityLanes struct {
+	// Number of parcels a waiting lane may be passed over before it is served, defaults to DefaultStarvationLimit
+	StarvationLimit int
+	// Number of parcels held per stage for reordering, defaults to DefaultLaneCapacity
+	Capacity int
+}
+
+type lane struct {
+	priority Priority
+	parcels  []*Parcel
+	skipped  int
+}
+
+// Ordered set of lanes, highest priority first.
+type lanes struct {
+	lanes []*lane
+	size  int
+	limit int
+}
+
+func newLanes(limit int) *lanes {
+	if limit <= 0 {
+		limit = DefaultStarvationLimit
+	}
+	return &lanes{
+		lanes: make([]*lane, 0),
+		limit: limit,
+	}
+}
+
+func (lanes *lanes) push(parcel *Parcel) {
+	index := sort.Search(len(lanes.lanes), func(i int) bool {
+		return lanes.lanes[i].priority <= parcel.Priority
+	})
+	if index == len(lanes.lanes) || lanes.lanes[index].priority != parcel.Priority {
+		lanes.lanes = append(lanes.lanes, nil)
+		copy(lanes.lanes[index+1:], lanes.lanes[index:])
+		lanes.lanes[index] = &lane{priority: parcel.Priority}
+	}
+	lanes.lanes[index].parcels = append(lanes.lanes[index].parcels, parcel)
+	lanes.size++
+}
+
+// Lane to be served next, a lane passed over too often wins over higher priorities.
+func (lanes *lanes) next() *lane {
+	var next *lane
+	for _, lane := range lanes.lanes {
+		if len(lane.parcels) == 0 {
+			continue
+		}
+		if next == nil || lane.skipped >= lanes.limit {
+			next = lane
+		}
+	}
+	return next
+}
+
+func (lanes *lanes) peek() *Parcel {
+	if next := lanes.next(); next != nil {
+		return next.parcels[0]
+	}
+	return nil
+}
+
+func (lanes *lanes) pop() *Parcel {
+	next := lanes.next()
+	if next == nil {
+		return nil
+	}
+
+	for _, lane := range lanes.lanes {
+		if lane != next && len(lane.parcels) > 0 {
+			lane.skipped++
+		}
+	}
+	next.skipped = 0
+
+	parcel := next.parcels[0]
+	next.parcels[0] = nil
+	next.parcels = next.parcels[1:]
+	lanes.size--
+	return parcel
+}
+
+func (lanes *lanes) clear() {
+	lanes.lanes = lanes.lanes[:0]
+	lanes.size = 0
+}
+
+// Reorders the parcels of the sender by priority, holding atmost capacity parcels to preserve back pressure.
+func newPrioritizerConnector(ctx context.Context, wg *sync.WaitGroup, sender chan *Parcel, capacity int, limit int) chan *Parcel {
+	if capacity <= 0 {
+		capacity = DefaultLaneCapacity
+	}
+
+	receiver := make(chan *Parcel)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(receiver)
+
+		queue := newLanes(limit)
+		inbound := sender
+		for inbound != nil || queue.size > 0 {
+			receive := inbound
+			if queue.size >= capacity {
+				receive = nil
+			}
+
+			var send chan *Parcel
+			next := queue.peek()
+			if next != nil {
+				send = receiver
+			}
+
+			select {
+			case parcel, ok := <-receive:
+				if !ok {
+					inbound = nil
+					continue
+				}
+				queue.push(parcel)
+			case send <- next:
+				queue.pop()
+			case <-ctx.Done():
+				queue.clear()
+				if inbound != nil {
+					for range inbound {
+					}
+					inbound = nil
+				}
+			}
+		}
+	}()
+
+	return receiver
+}
@@ -0,0 +1,103 @@
+package conveyor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLanesServeHighestPriorityFirst(t *testing.T) {
+	queue := newLanes(100)
+	for i, priority := range []Priority{NormalPriority, LowPriority, HighPriority, NormalPriority, HighPriority} {
+		queue.push(&Parcel{Sequence: i, Priority: priority})
+	}
+
+	sequences := make([]int, 0)
+	for queue.size > 0 {
+		sequences = append(sequences, queue.pop().Sequence)
+	}
+	assert.Equal(t, []int{2, 4, 0, 3, 1}, sequences)
+}
+
+func TestLanesPreventStarvation(t *testing.T) {
+	queue := newLanes(2)
+	queue.push(&Parcel{Sequence: 0, Priority: LowPriority})
+	for i := 1; i <= 4; i++ {
+		queue.push(&Parcel{Sequence: i, Priority: HighPriority})
+	}
+
+	sequences := make([]int, 0)
+	for queue.size > 0 {
+		sequences = append(sequences, queue.pop().Sequence)
+	}
+	assert.Equal(t, []int{1, 2, 0, 3, 4}, sequences)
+}
+
+func TestPriorityLanes(t *testing.T) {
+	// Lanes reorder regardless of the stage's buffer
+	for _, bufferSize := range []uint{20, 0} {
+		numIter := 20
+		start := make(chan struct{})
+		mutex := &sync.Mutex{}
+		received := make([]Priority, 0)
+		runner := New(&Options{PriorityLanes: &PriorityLanes{StarvationLimit: 100}}).
+			AddSource(&Stage{
+				Name: "generate",
+				Process: func(parcel *Parcel) interface{} {
+					<-start
+					if parcel.Sequence >= numIter {
+						return Stop
+					}
+					if parcel.Sequence%3 == 0 {
+						parcel.Priority = HighPriority
+					}
+					return parcel.Sequence
+				},
+			}).
+			AddSink(&Stage{
+				Name:       "write",
+				BufferSize: bufferSize,
+				Process: func(parcel *Parcel) interface{} {
+					mutex.Lock()
+					defer mutex.Unlock()
+					received = append(received, parcel.Priority)
+					return nil
+				},
+			}).Build().DispatchBackground()
+
+		runner.PauseStage("write")
+		close(start)
+		assert.Eventually(t, func() bool { return runner.Snapshot().Stages[0].Disposed }, time.Second, time.Millisecond)
+		runner.ResumeStage("write")
+		runner.Wait()
+
+		assert.Len(t, received, numIter)
+		for i, priority := range received {
+			if i < 7 {
+				assert.Equal(t, HighPriority, priority)
+			} else {
+				assert.Equal(t, NormalPriority, priority)
+			}
+		}
+	}
+}
+
+func TestServiceSubmitWithPriority(t *testing.T) {
+	service := New(&Options{PriorityLanes: &PriorityLanes{}}).
+		AddSource(&Stage{}).
+		AddSink(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				return parcel.Priority
+			},
+		}).Build().Serve(context.Background())
+	defer service.Close()
+
+	future, err := service.Submit(context.Background(), "urgent", WithPriority(HighPriority))
+	assert.NoError(t, err)
+	result, err := future.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, HighPriority, result)
+}
@@ -0,0 +1,50 @@
+package conveyor
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Creates a process from the parameters given in a pipeline definition.
+type ProcessFactory func(params map[string]interface{}) (Process, error)
+
+// Named process factories referenced by declarative pipeline definitions.
+type Registry struct {
+	mutex     *sync.RWMutex
+	factories map[string]ProcessFactory
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		mutex:     &sync.RWMutex{},
+		factories: make(map[string]ProcessFactory),
+	}
+}
+
+func (registry *Registry) Register(name string, factory ProcessFactory) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	if factory == nil {
+		panic(fmt.Sprintf("process factory '%s' is nil", name))
+	}
+	if _, ok := registry.factories[name]; ok {
+		panic(fmt.Sprintf("process factory '%s' is already registered", name))
+	}
+	registry.factories[name] = factory
+}
+
+// Registers a process which does not take any parameters.
+func (registry *Registry) RegisterProcess(name string, process Process) {
+	registry.Register(name, func(params map[string]interface{}) (Process, error) {
+		return process, nil
+	})
+}
+
+func (registry *Registry) lookup(name string) (ProcessFactory, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	factory, ok := registry.factories[name]
+	return factory, ok
+}
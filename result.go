@@ -0,0 +1,49 @@
+package conveyor
+
+import (
+	"errors"
+	"sort"
+)
+
+var (
+	ErrSkipped = errors.New("conveyor: parcel was skipped")
+	ErrFailure = errors.New("conveyor: parcel failed processing")
+)
+
+// Outcome of a parcel processed by the sink.
+type Result struct {
+	Sequence int
+	ID       ParcelID
+	Content  interface{}
+	Err      error
+}
+
+func newResult(parcel *Parcel, content interface{}) Result {
+	result := Result{Sequence: parcel.Sequence, ID: parcel.ID()}
+	switch content {
+	case Skip, Stop:
+		result.Err = ErrSkipped
+	case Failure:
+		result.Err = ErrFailure
+	default:
+		result.Content = content
+	}
+	return result
+}
+
+// Drains the results of a runner dispatched with Options.CollectResults,
+// optionally ordered by sequence.
+func Collect(runner *Runner, ordered bool) []Result {
+	results := make([]Result, 0)
+	for result := range runner.Results() {
+		results = append(results, result)
+	}
+
+	if ordered {
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Sequence < results[j].Sequence
+		})
+	}
+
+	return results
+}
@@ -0,0 +1,74 @@
+package conveyor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectOrderedResults(t *testing.T) {
+	numIter := 100
+	runner := New(&Options{CollectResults: true}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= numIter {
+					return Stop
+				}
+				if parcel.Sequence%10 == 0 {
+					return Skip
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddSink(&Stage{
+			MaxScale: 8,
+			Process: func(parcel *Parcel) interface{} {
+				return parcel.Content.(int) * 2
+			},
+		}).Build().DispatchWithTimeout(time.Second)
+
+	results := Collect(runner, true)
+	runner.Wait()
+
+	assert.Len(t, results, numIter)
+	for i, result := range results {
+		assert.Equal(t, i, result.Sequence)
+		if i%10 == 0 {
+			assert.ErrorIs(t, result.Err, ErrSkipped)
+		} else {
+			assert.NoError(t, result.Err)
+			assert.Equal(t, i*2, result.Content)
+		}
+	}
+}
+
+func TestCollectResultsFromMultipleSinks(t *testing.T) {
+	numIter := 10
+	runner := New(&Options{CollectResults: true}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= numIter {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		Fanout(&Stage{}, &Stage{}).
+		AddSinks(&Stage{}, &Stage{}).Build().DispatchWithTimeout(time.Second)
+
+	assert.Len(t, Collect(runner, false), numIter*2)
+}
+
+func TestResultsWithoutCollecting(t *testing.T) {
+	runner := New(nil).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				return Stop
+			},
+		}).
+		AddSink(&Stage{}).Build().DispatchBackground()
+
+	runner.Wait()
+	assert.Empty(t, Collect(runner, true))
+}
@@ -1,25 +1,176 @@
 package conveyor
 
-import "sync"
+import (
+	"context"
+	"sync"
+)
 
 type Runner struct {
-	wg *sync.WaitGroup
+	name     string
+	wg       *sync.WaitGroup
+	results  chan Result
+	monitors []*monitor
+	gate     *gate
+	gates    []*gate
+	done     chan struct{}
+	cancel   context.CancelFunc
+	mutex    *sync.Mutex
+	err      error
 }
 
 func (runner *Runner) Wait() {
 	runner.wg.Wait()
 }
 
-func newRunner(wg *sync.WaitGroup) *Runner {
+// Sink results, only published when dispatched with Options.CollectResults.
+// The channel must be drained for the conveyor to finish and is closed once the sink is done.
+func (runner *Runner) Results() <-chan Result {
+	if runner.results == nil {
+		results := make(chan Result)
+		close(results)
+		return results
+	}
+	return runner.results
+}
+
+// Current state of every stage in the conveyor.
+func (runner *Runner) Snapshot() Snapshot {
+	snapshot := Snapshot{
+		Name:   runner.name,
+		Paused: len(runner.gates) > 0 && runner.gates[0].isPaused(),
+		Stages: make([]StageSnapshot, 0, len(runner.monitors)),
+	}
+	for _, monitor := range runner.monitors {
+		snapshot.Stages = append(snapshot.Stages, monitor.snapshot())
+	}
+	return snapshot
+}
+
+// Diagnostic error of an aborted run, e.g. ErrStalled raised by the watchdog, or the first
+// ErrStorage of a run which restored or snapshotted a cache unsuccessfully.
+func (runner *Runner) Err() error {
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+	return runner.err
+}
+
+func (runner *Runner) abort(err error) {
+	runner.mutex.Lock()
+	if runner.err == nil {
+		runner.err = err
+	}
+	runner.mutex.Unlock()
+
+	if runner.cancel != nil {
+		runner.cancel()
+	}
+}
+
+// Records the error without aborting the run.
+func (runner *Runner) fail(err error) {
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+	if runner.err == nil {
+		runner.err = err
+	}
+}
+
+func (runner *Runner) finish() {
+	go func() {
+		runner.wg.Wait()
+		if runner.cancel != nil {
+			runner.cancel()
+		}
+		close(runner.done)
+	}()
+}
+
+func newRunner(wg *sync.WaitGroup, results chan Result) *Runner {
 	return &Runner{
-		wg: wg,
+		wg:      wg,
+		results: results,
+		done:    make(chan struct{}),
+		mutex:   &sync.Mutex{},
+	}
+}
+
+// Halts the source(s) from generating new parcels while keeping stages, buffers and caches alive.
+func (runner *Runner) Pause() {
+	for _, gate := range runner.gates {
+		gate.pause()
+	}
+}
+
+func (runner *Runner) Resume() {
+	runner.touch()
+	for _, gate := range runner.gates {
+		gate.resume()
+	}
+}
+
+// Halts every stage with the given name from taking new work, false when no stage matched.
+func (runner *Runner) PauseStage(name string) bool {
+	handle := runner.Stage(name)
+	if handle == nil {
+		return false
+	}
+	handle.Pause()
+	return true
+}
+
+func (runner *Runner) ResumeStage(name string) bool {
+	runner.touch()
+	handle := runner.Stage(name)
+	if handle == nil {
+		return false
+	}
+	handle.Resume()
+	return true
+}
+
+// Runtime handle of every dispatched stage with the given name, nil when no stage matched.
+func (runner *Runner) Stage(name string) *StageHandle {
+	monitors := make([]*monitor, 0)
+	for _, monitor := range runner.monitors {
+		if monitor.stage.Name == name {
+			monitors = append(monitors, monitor)
+		}
+	}
+	if len(monitors) == 0 {
+		return nil
+	}
+	return &StageHandle{runner: runner, monitors: monitors}
+}
+
+func (runner *Runner) isPaused() bool {
+	for _, gate := range runner.gates {
+		if gate.isPaused() {
+			return true
+		}
+	}
+	for _, monitor := range runner.monitors {
+		if monitor.gate.isPaused() {
+			return true
+		}
+	}
+	return false
+}
+
+// Paused time does not count as lack of progress.
+func (runner *Runner) touch() {
+	for _, monitor := range runner.monitors {
+		monitor.touch()
 	}
 }
 
 //
 func JoinRunners(runners ...*Runner) *Runner {
 	wg := &sync.WaitGroup{}
+	monitors := make([]*monitor, 0)
+	gates := make([]*gate, 0)
 	for _, runner := range runners {
+		monitors = append(monitors, runner.monitors...)
+		gates = append(gates, runner.gates...)
 		wg.Add(1)
 		go func(runner *Runner) {
 			defer wg.Done()
@@ -27,5 +178,9 @@ func JoinRunners(runners ...*Runner) *Runner {
 		}(runner)
 	}
 
-	return newRunner(wg)
+	joined := newRunner(wg, nil)
+	joined.monitors = monitors
+	joined.gates = gates
+	joined.finish()
+	return joined
 }
@@ -1,6 +1,7 @@
 package conveyor
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -29,3 +30,121 @@ func TestJoinRunners(t *testing.T) {
 
 	assert.Less(t, time.Since(ts1), time.Second*2)
 }
+
+func TestPauseAndResumeRunner(t *testing.T) {
+	numIter := 50
+	runner := New(&Options{CollectResults: true}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= numIter {
+					return Stop
+				}
+				time.Sleep(time.Millisecond)
+				return parcel.Sequence
+			},
+		}).
+		AddSink(&Stage{}).Build().DispatchBackground()
+
+	results := make(chan []Result)
+	go func() { results <- Collect(runner, true) }()
+
+	assert.Eventually(t, func() bool { return runner.Snapshot().Stages[0].Processed > 5 }, time.Second, time.Millisecond)
+	runner.Pause()
+	assert.True(t, runner.Snapshot().Paused)
+	time.Sleep(10 * time.Millisecond)
+	processed := runner.Snapshot().Stages[0].Processed
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, processed, runner.Snapshot().Stages[0].Processed)
+	assert.Less(t, processed, uint64(numIter))
+
+	runner.Resume()
+	assert.Len(t, <-results, numIter)
+	runner.Wait()
+}
+
+func TestPauseAndResumeStage(t *testing.T) {
+	numIter := 50
+	runner := New(nil).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= numIter {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddSink(&Stage{
+			Name:       "write",
+			BufferSize: 5,
+			Process: func(parcel *Parcel) interface{} {
+				parcel.Cache.Set(fmt.Sprintf("%d", parcel.Sequence), true)
+				return nil
+			},
+			Dispose: func(cache *Cache) {
+				assert.Equal(t, numIter, cache.Count())
+			},
+		}).Build()
+
+	assert.False(t, JoinRunners().PauseStage("write"))
+	dispatched := runner.DispatchBackground()
+	assert.True(t, dispatched.PauseStage("write"))
+	time.Sleep(20 * time.Millisecond)
+
+	snapshot := dispatched.Snapshot()
+	assert.True(t, snapshot.Stages[1].Paused)
+	assert.Less(t, snapshot.Stages[1].Processed, uint64(numIter))
+	assert.False(t, snapshot.Stages[0].Disposed)
+
+	assert.True(t, dispatched.ResumeStage("write"))
+	dispatched.Wait()
+}
+
+func TestPausedStageTakesNoParcels(t *testing.T) {
+	numIter := 3
+	runner := New(nil).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= numIter {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddSink(&Stage{Name: "write", BufferSize: 5}).Build()
+
+	dispatched := runner.DispatchBackground()
+	assert.True(t, dispatched.PauseStage("write"))
+	assert.Eventually(t, func() bool { return dispatched.Snapshot().Stages[0].Disposed }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+
+	snapshot := dispatched.Snapshot()
+	assert.Equal(t, uint64(0), snapshot.Stages[1].Processed)
+	assert.Equal(t, 0, snapshot.Stages[1].InFlight)
+	assert.Equal(t, numIter, snapshot.Stages[1].Inbound.Len)
+
+	assert.True(t, dispatched.ResumeStage("write"))
+	dispatched.Wait()
+	assert.Equal(t, uint64(numIter), dispatched.Snapshot().Stages[1].Processed)
+}
+
+func TestTimedOutRunEndsPausedStage(t *testing.T) {
+	runner := New(nil).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				return parcel.Sequence
+			},
+		}).
+		AddSink(&Stage{Name: "write"}).Build().DispatchWithTimeout(20 * time.Millisecond)
+	runner.PauseStage("write")
+
+	done := make(chan struct{})
+	go func() {
+		runner.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("paused stage outlived the run's timeout")
+	}
+}
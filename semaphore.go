@@ -0,0 +1,142 @@
+package conveyor
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Counting semaphore whose limit can be changed while it is held.
+type semaphore struct {
+	mutex   *sync.Mutex
+	limit   int
+	count   int
+	waiting int
+	changed chan struct{}
+}
+
+// Spaces out acquisitions to a maximum rate per second, unlimited when zero.
+type limiter struct {
+	mutex    *sync.Mutex
+	rate     float64
+	interval time.Duration
+	next     time.Time
+}
+
+func newSemaphore(limit int) *semaphore {
+	return &semaphore{
+		mutex:   &sync.Mutex{},
+		limit:   limit,
+		changed: make(chan struct{}),
+	}
+}
+
+func (semaphore *semaphore) acquire(ctx context.Context) bool {
+	for {
+		semaphore.mutex.Lock()
+		if semaphore.count < semaphore.limit {
+			semaphore.count++
+			semaphore.mutex.Unlock()
+			return true
+		}
+		semaphore.waiting++
+		changed := semaphore.changed
+		semaphore.mutex.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+		}
+
+		semaphore.mutex.Lock()
+		semaphore.waiting--
+		semaphore.mutex.Unlock()
+		if ctx.Err() != nil {
+			return false
+		}
+	}
+}
+
+func (semaphore *semaphore) release() {
+	semaphore.mutex.Lock()
+	defer semaphore.mutex.Unlock()
+
+	semaphore.count--
+	semaphore.signal()
+}
+
+func (semaphore *semaphore) resize(limit int) {
+	semaphore.mutex.Lock()
+	defer semaphore.mutex.Unlock()
+
+	semaphore.limit = limit
+	semaphore.signal()
+}
+
+func (semaphore *semaphore) size() int {
+	semaphore.mutex.Lock()
+	defer semaphore.mutex.Unlock()
+	return semaphore.limit
+}
+
+func (semaphore *semaphore) signal() {
+	if semaphore.waiting > 0 {
+		close(semaphore.changed)
+		semaphore.changed = make(chan struct{})
+	}
+}
+
+func newLimiter(rate float64) *limiter {
+	limiter := &limiter{
+		mutex: &sync.Mutex{},
+	}
+	limiter.setRate(rate)
+	return limiter
+}
+
+func (limiter *limiter) setRate(rate float64) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	limiter.rate = rate
+	limiter.interval = 0
+	if rate > 0 {
+		limiter.interval = time.Duration(float64(time.Second) / rate)
+	}
+}
+
+func (limiter *limiter) getRate() float64 {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	return limiter.rate
+}
+
+func (limiter *limiter) wait(ctx context.Context) bool {
+	limiter.mutex.Lock()
+	if limiter.interval <= 0 {
+		limiter.mutex.Unlock()
+		return true
+	}
+
+	now := time.Now()
+	if limiter.next.Before(now) {
+		limiter.next = now
+	}
+	at := limiter.next
+	limiter.next = limiter.next.Add(limiter.interval)
+	limiter.mutex.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
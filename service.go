@@ -0,0 +1,270 @@
+package conveyor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrServiceClosed = errors.New("conveyor: service is closed")
+)
+
+// Long-lived conveyor fed by submitted items instead of a generating source.
+// The source stage receives every submitted item as parcel content.
+type Service struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	submissions chan *submission
+	closing     chan struct{}
+	done        chan struct{}
+	closeOnce   *sync.Once
+	mutex       *sync.Mutex
+	futures     map[int]*Future
+	sequence    int
+	runner      *Runner
+}
+
+// Result of a submitted item, resolved once the sink has processed it.
+type Future struct {
+	done    chan struct{}
+	content interface{}
+	err     error
+	// Parcels of the item still on the conveyor
+	pending int
+	// Skip or failure the settled parcels ended in, resolved once none is pending
+	outcome *Result
+}
+
+type submission struct {
+	content  interface{}
+	sequence int
+	priority Priority
+	meta     Meta
+}
+
+type SubmitOption func(submission *submission)
+
+// Submits the item carrying the given metadata.
+func WithMeta(meta Meta) SubmitOption {
+	return func(submission *submission) {
+		submission.meta = meta
+	}
+}
+
+// Submits the item with the given priority, only effective with Options.PriorityLanes.
+func WithPriority(priority Priority) SubmitOption {
+	return func(submission *submission) {
+		submission.priority = priority
+	}
+}
+
+func newService(ctx context.Context) *Service {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Service{
+		ctx:         ctx,
+		cancel:      cancel,
+		submissions: make(chan *submission),
+		closing:     make(chan struct{}),
+		done:        make(chan struct{}),
+		closeOnce:   &sync.Once{},
+		mutex:       &sync.Mutex{},
+		futures:     make(map[int]*Future),
+	}
+}
+
+func newFuture() *Future {
+	return &Future{
+		done:    make(chan struct{}),
+		pending: 1,
+	}
+}
+
+func (service *Service) start(runner *Runner) {
+	service.runner = runner
+	go func() {
+		runner.Wait()
+		service.cancel()
+
+		service.mutex.Lock()
+		defer service.mutex.Unlock()
+		for sequence, future := range service.futures {
+			future.resolve(nil, ErrServiceClosed)
+			delete(service.futures, sequence)
+		}
+		close(service.done)
+	}()
+}
+
+// Pushes an item into the source stage, the returned future is resolved with the first result
+// the sink produces for the item. A skip or failure only resolves it once every branch and split
+// of the item has reached the sink or been dropped without producing a result.
+func (service *Service) Submit(ctx context.Context, item interface{}, opts ...SubmitOption) (*Future, error) {
+	select {
+	case <-service.closing:
+		return nil, ErrServiceClosed
+	case <-service.done:
+		return nil, ErrServiceClosed
+	default:
+	}
+
+	future := newFuture()
+	service.mutex.Lock()
+	sequence := service.sequence
+	service.sequence++
+	service.futures[sequence] = future
+	service.mutex.Unlock()
+
+	submitted := &submission{content: item, sequence: sequence}
+	for _, opt := range opts {
+		opt(submitted)
+	}
+
+	var err error
+	select {
+	case service.submissions <- submitted:
+		return future, nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-service.ctx.Done():
+		err = ErrServiceClosed
+	}
+
+	service.mutex.Lock()
+	delete(service.futures, sequence)
+	service.mutex.Unlock()
+	return nil, err
+}
+
+// Stops accepting submissions and waits for the submitted items to drain through the conveyor.
+func (service *Service) Close() {
+	service.closeOnce.Do(func() {
+		close(service.closing)
+		service.cancel()
+	})
+	<-service.done
+}
+
+func (service *Service) Runner() *Runner {
+	return service.runner
+}
+
+// Resolves the future with a result of the sink, a skip or failure is held back until no other
+// parcel of the item is pending. A failure outweighs a skip.
+func (service *Service) resolve(result Result) {
+	service.mutex.Lock()
+	future, ok := service.futures[result.Sequence]
+	if !ok {
+		service.mutex.Unlock()
+		return
+	}
+	if result.Err != nil {
+		if future.outcome == nil || errors.Is(future.outcome.Err, ErrSkipped) {
+			future.outcome = &result
+		}
+		service.mutex.Unlock()
+		return
+	}
+	delete(service.futures, result.Sequence)
+	service.mutex.Unlock()
+
+	future.resolve(result.Content, nil)
+}
+
+// Counts the parcels an item has on the conveyor, parcels sent add to it and parcels a stage is
+// done with take from it. Once none is left the future resolves with the held skip or failure.
+func (service *Service) track(sequence, delta int) {
+	service.mutex.Lock()
+	future, ok := service.futures[sequence]
+	if !ok {
+		service.mutex.Unlock()
+		return
+	}
+	future.pending += delta
+	if future.pending > 0 {
+		service.mutex.Unlock()
+		return
+	}
+	delete(service.futures, sequence)
+	service.mutex.Unlock()
+
+	if future.outcome == nil {
+		future.resolve(nil, ErrSkipped)
+		return
+	}
+	future.resolve(nil, future.outcome.Err)
+}
+
+func (future *Future) resolve(content interface{}, err error) {
+	future.content = content
+	future.err = err
+	close(future.done)
+}
+
+// Closed when the future is resolved.
+func (future *Future) Done() <-chan struct{} {
+	return future.done
+}
+
+// Blocks until the future is resolved or the context is done.
+func (future *Future) Get(ctx context.Context) (interface{}, error) {
+	select {
+	case <-future.done:
+		return future.content, future.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (stage *Stage) dispatchServed(arg *stageArg) {
+	arg.wg.Add(1)
+	go func() {
+		defer arg.wg.Done()
+
+		parcel := newParcel(nil, stage)
+		parcel.monitor = arg.monitor
+		parcel.ctx = arg.abort
+		parcel.pooled = arg.factory.pooledParcels
+		stage.init(arg, parcel.Cache)
+		defer arg.close()
+		defer stage.dispose(arg, parcel.Cache)
+
+		stage.logger.Information(stage, "served source start processing")
+		for arg.await(arg.ctx, arg.runner.gate, arg.monitor.gate) {
+			select {
+			case <-arg.ctx.Done():
+				continue
+			case <-arg.abort.Done():
+				continue
+			case submission := <-arg.service.submissions:
+				if !arg.monitor.limiter.wait(arg.abort) {
+					arg.service.resolve(Result{Sequence: submission.sequence, Err: ErrServiceClosed})
+					arg.service.track(submission.sequence, -1)
+					continue
+				}
+				parcel = parcel.unpack(&Parcel{
+					Stage:    stage,
+					Content:  submission.content,
+					Sequence: submission.sequence,
+					Priority: submission.priority,
+					Meta:     submission.meta,
+					Logger:   stage.logger,
+				})
+
+				arg.monitor.begin()
+				result := stage.CircuitBreaker.Execute(stage, parcel)
+				if result == Stop {
+					stage.logger.EnqueueDebug(stage, parcel, fmt.Sprintf("served source yielded 'Stop' for parcel '%d', treating it as 'Skip'", parcel.Sequence))
+					result = Skip
+				}
+				// The submitted item is done once its parcels are sent, resolving it as skipped when none was
+				stage.deliver(arg, parcel, result)
+				arg.track(parcel, -1)
+				arg.monitor.end(parcel)
+			}
+		}
+
+		stage.logger.Information(stage, "served source done processing, quitting")
+	}()
+}
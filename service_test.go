@@ -0,0 +1,182 @@
+package conveyor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceSubmitResolvesSinkResult(t *testing.T) {
+	initialized := 0
+	service := New(nil).
+		AddSource(&Stage{
+			Init: func(cache *Cache) {
+				initialized++
+			},
+		}).
+		AddStage(&Stage{
+			MaxScale: 4,
+			Process: func(parcel *Parcel) interface{} {
+				return parcel.Content.(int) * 2
+			},
+		}).
+		AddSink(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				return parcel.Content.(int) + 1
+			},
+		}).Build().Serve(context.Background())
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			future, err := service.Submit(context.Background(), i)
+			assert.NoError(t, err)
+			result, err := future.Get(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, i*2+1, result)
+		}(i)
+	}
+	wg.Wait()
+	service.Close()
+
+	assert.Equal(t, 1, initialized)
+	_, err := service.Submit(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrServiceClosed)
+}
+
+func TestServiceSubmitSkippedAndFailed(t *testing.T) {
+	service := New(nil).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				switch parcel.Content {
+				case "skip":
+					return Skip
+				case "fail":
+					panic("test")
+				}
+				return parcel.Content
+			},
+		}).
+		AddSink(&Stage{}).Build().Serve(context.Background())
+	defer service.Close()
+
+	future, err := service.Submit(context.Background(), "skip")
+	assert.NoError(t, err)
+	_, err = future.Get(context.Background())
+	assert.ErrorIs(t, err, ErrSkipped)
+
+	future, err = service.Submit(context.Background(), "fail")
+	assert.NoError(t, err)
+	_, err = future.Get(context.Background())
+	assert.ErrorIs(t, err, ErrFailure)
+
+	future, err = service.Submit(context.Background(), "ok")
+	assert.NoError(t, err)
+	result, err := future.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", result)
+}
+
+func TestServiceResolvesEmptySplitsInSegments(t *testing.T) {
+	service := New(nil).
+		AddSource(&Stage{}).
+		AddStage(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Content == "iter" {
+					return UnpackIter{Iter: func(yield func(content interface{}) bool) {}}
+				}
+				return Unpack{}
+			},
+		}).
+		AddSink(&Stage{}).Build().Serve(context.Background())
+	defer service.Close()
+
+	for _, content := range []string{"data", "iter"} {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		future, err := service.Submit(ctx, content)
+		assert.NoError(t, err)
+		_, err = future.Get(ctx)
+		assert.ErrorIs(t, err, ErrSkipped)
+		cancel()
+	}
+}
+
+func TestServiceWaitsForEveryBranch(t *testing.T) {
+	service := New(nil).
+		AddSource(&Stage{}).
+		Fanout(&Stage{
+			Name: "empty",
+			Process: func(parcel *Parcel) interface{} {
+				return Unpack{}
+			},
+		}, &Stage{
+			Name: "split",
+			Process: func(parcel *Parcel) interface{} {
+				return Unpack{Data: []interface{}{"skipped", parcel.Content}}
+			},
+		}).
+		AddSinks(&Stage{}, &Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Content == "skipped" {
+					return Skip
+				}
+				time.Sleep(50 * time.Millisecond)
+				return parcel.Content
+			},
+		}).Build().Serve(context.Background())
+	defer service.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	future, err := service.Submit(ctx, "data")
+	assert.NoError(t, err)
+	content, err := future.Get(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "data", content)
+}
+
+func TestServiceIgnoresCollectResults(t *testing.T) {
+	service := New(&Options{CollectResults: true}).
+		AddSource(&Stage{}).
+		AddSink(&Stage{BufferSize: 1}).Build().Serve(context.Background())
+	defer service.Close()
+
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		future, err := service.Submit(ctx, i)
+		assert.NoError(t, err)
+		content, err := future.Get(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, i, content)
+		cancel()
+	}
+}
+
+func TestServiceResolvesJoinedBranches(t *testing.T) {
+	service := New(nil).
+		AddSource(&Stage{}).
+		Fanout(&Stage{
+			Name: "empty",
+			Process: func(parcel *Parcel) interface{} {
+				return Unpack{}
+			},
+		}, &Stage{Name: "same"}).
+		Join(&Stage{}, &JoinPolicy{Timeout: 20 * time.Millisecond, OnTimeout: JoinOmit}).
+		AddSink(&Stage{}).Build().Serve(context.Background())
+	defer service.Close()
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		future, err := service.Submit(ctx, i)
+		assert.NoError(t, err)
+		content, err := future.Get(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, Joined{"same": i}, content)
+		cancel()
+	}
+}
@@ -0,0 +1,34 @@
+package conveyor
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Hands out sequences unique across every source of a conveyor.
+type sequencer struct {
+	next int64
+}
+
+func (sequencer *sequencer) claim() int {
+	return int(atomic.AddInt64(&sequencer.next, 1) - 1)
+}
+
+// Sources merged into the following stage, the conveyor ends once every source returned Stop.
+// Sequences are unique across the sources, a source therefore observes gaps in its own sequences.
+func (builder *builder) AddSources(stages ...*Stage) IStage {
+	if len(stages) == 0 {
+		panic("atleast one source must be given")
+	}
+
+	builder.mutex.Lock()
+	defer builder.mutex.Unlock()
+
+	if len(builder.stages) > 0 {
+		panic(fmt.Sprintf("sources must be the first segment, the conveyor already has '%d'", len(builder.stages)))
+	}
+
+	builder.verifyInput(stages...)
+	builder.stages = append(builder.stages, stages)
+	return builder
+}
@@ -0,0 +1,56 @@
+package conveyor
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCountingSource(name string, limit int) *Stage {
+	return &Stage{
+		Name: name,
+		Init: func(cache *Cache) {
+			cache.Set("count", new(int64))
+		},
+		Process: func(parcel *Parcel) interface{} {
+			count, _ := parcel.Cache.Get("count")
+			n := atomic.AddInt64(count.(*int64), 1)
+			if n > int64(limit) {
+				return Stop
+			}
+			return fmt.Sprintf("%s-%d", name, n)
+		},
+	}
+}
+
+func TestAddSourcesMergesIntoFirstStage(t *testing.T) {
+	runner := New(&Options{CollectResults: true}).
+		AddSources(newCountingSource("stream", 10), newCountingSource("ticker", 5)).
+		AddStage(&Stage{MaxScale: 2}).
+		AddSink(&Stage{}).Build().DispatchWithTimeout(time.Second)
+
+	sequences := make(map[int]bool)
+	contents := make(map[string]bool)
+	for _, result := range Collect(runner, false) {
+		assert.False(t, sequences[result.Sequence])
+		sequences[result.Sequence] = true
+		contents[result.Content.(string)] = true
+	}
+
+	assert.Len(t, sequences, 15)
+	assert.True(t, contents["stream-10"])
+	assert.True(t, contents["ticker-5"])
+	assert.Len(t, runner.Snapshot().Stages, 4)
+}
+
+func TestAddSourcesMustMergeBeforeFanout(t *testing.T) {
+	assert.Panics(t, func() {
+		New(nil).AddSources(&Stage{}, &Stage{}).Fanout(&Stage{}, &Stage{})
+	})
+	assert.Panics(t, func() {
+		New(nil).AddSources()
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 )
 
 type Process func(parcel *Parcel) interface{}
@@ -12,23 +13,55 @@ type Stage struct {
 	Name       string
 	MaxScale   uint
 	BufferSize uint
+	Timeout    time.Duration
+	RateLimit  float64
+	// Number of concurrently generating partitions of a source, each with its own Parcel.Partition
+	// and Parcel.PartitionSequence cursor, sharing the source's cache
+	Partitions int
+	Execution  ExecutionModel
+	// Micro-batches the inbound edge, disabled when nil
+	Batch *Batching
 
 	Init    func(cache *Cache)
 	Process Process
 	Dispose func(cache *Cache)
 
+	// Per-worker state of a segment or sink, setting either runs MaxScale long-lived workers
+	// each owning the worker cache passed on as Parcel.Worker
+	WorkerInit    func(worker *Cache)
+	WorkerDispose func(worker *Cache)
+
 	CircuitBreaker ICircuitBreaker
 	ErrorHandler   IErrorHandler
+	Storage        IStorage
 	logger         ILogger
+	storageKey     string
+
+	composite *composite
+	join      *join
+	keyedJoin *KeyedJoin
 }
 
 type stageArg struct {
-	ctx      context.Context
-	wg       *sync.WaitGroup
-	factory  *factory
-	inbound  chan *Parcel
-	outbound chan *Parcel
-	flushMsg chan *flushMessage
+	ctx       context.Context
+	abort     context.Context
+	wg        *sync.WaitGroup
+	factory   *factory
+	inbound   chan *Parcel
+	outbound  chan *Parcel
+	flushMsg  chan flushMessage
+	sinks     *sync.WaitGroup
+	service   *Service
+	results   chan Result
+	runner    *Runner
+	monitor   *monitor
+	branch    *Hop
+	sequencer *sequencer
+	batcher   *batcher
+	batches   chan []*Parcel
+	batchers  map[chan *Parcel]*batcher
+	// Parcels the outbound edge makes of every parcel sent, the width of a following fanout
+	copies int
 }
 
 const (
@@ -38,6 +71,144 @@ const (
 	MaxBufferSize     = 10000
 )
 
+func (arg *stageArg) resolve(parcel *Parcel, content interface{}) {
+	if arg.service == nil && arg.results == nil {
+		return
+	}
+
+	result := newResult(parcel, content)
+	if arg.service != nil {
+		arg.service.resolve(result)
+	}
+	if arg.results != nil {
+		select {
+		case arg.results <- result:
+		case <-arg.abort.Done():
+		}
+	}
+}
+
+// Counts the parcels a served item has on the conveyor, see Service.track.
+func (arg *stageArg) track(parcel *Parcel, delta int) {
+	if arg.service != nil {
+		arg.service.track(parcel.Sequence, delta)
+	}
+}
+
+// Claims a conveyor wide sequence for the parcel when merging multiple sources.
+func (arg *stageArg) sequence(parcel *Parcel) {
+	if arg.sequencer != nil {
+		parcel.Sequence = arg.sequencer.claim()
+	}
+}
+
+func (arg *stageArg) send(parcel *Parcel) bool {
+	arg.track(parcel, arg.copies)
+	if arg.batcher != nil {
+		return arg.batcher.add(parcel)
+	}
+
+	select {
+	case arg.outbound <- parcel:
+		return true
+	default:
+	}
+
+	arg.monitor.blocked(1)
+	defer arg.monitor.blocked(-1)
+	select {
+	case arg.outbound <- parcel:
+		return true
+	case <-arg.abort.Done():
+		return false
+	}
+}
+
+// Calls fn for every inbound parcel until the inbound edge is closed, taking batched edges apart.
+// A paused stage takes no parcel until it is resumed, the parcels left once the run ended are dropped.
+func (arg *stageArg) receive(fn func(parcel *Parcel)) {
+	open := func() bool { return arg.await(arg.abort, arg.monitor.gate) }
+	if arg.batches == nil {
+		for open() {
+			parcel, ok := <-arg.inbound
+			if !ok {
+				return
+			}
+			fn(parcel)
+		}
+		for parcel := range arg.inbound {
+			parcel.release()
+		}
+		return
+	}
+
+	for open() {
+		batch, ok := <-arg.batches
+		if !ok {
+			return
+		}
+		for k, parcel := range batch {
+			if k > 0 && !open() {
+				for _, dropped := range batch[k:] {
+					dropped.release()
+				}
+				break
+			}
+			fn(parcel)
+		}
+	}
+	for batch := range arg.batches {
+		for _, parcel := range batch {
+			parcel.release()
+		}
+	}
+}
+
+// Closes the outbound edge once the stage stopped sending.
+func (arg *stageArg) close() {
+	if arg.batcher != nil {
+		arg.batcher.close()
+	} else {
+		close(arg.outbound)
+	}
+}
+
+// Blocks while any of the gates is paused, false when the context is done or the conveyor aborted.
+// A paused gate also gives up once the run's context is done.
+func (arg *stageArg) await(ctx context.Context, gates ...*gate) bool {
+	if ctx.Err() != nil || arg.abort.Err() != nil {
+		return false
+	}
+
+	for _, gate := range gates {
+		select {
+		case <-gate.opened():
+			continue
+		default:
+		}
+
+		select {
+		case <-gate.opened():
+		case <-ctx.Done():
+			return false
+		case <-arg.abort.Done():
+			return false
+		case <-arg.ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// Waits for the stage's rate limit and a free slot within its scale, false when the conveyor aborted.
+func (arg *stageArg) acquire() bool {
+	return arg.monitor.limiter.wait(arg.abort) && arg.monitor.semaphore.acquire(arg.abort)
+}
+
+func (arg *stageArg) release() {
+	arg.monitor.semaphore.release()
+}
+
 func (stage *Stage) tidy(options *Options) {
 	if stage.Dispose == nil {
 		stage.Dispose = func(cache *Cache) {}
@@ -59,6 +230,18 @@ func (stage *Stage) tidy(options *Options) {
 		stage.MaxScale = 1
 	}
 
+	if stage.RateLimit < 0 {
+		stage.RateLimit = 0
+	}
+
+	if stage.Partitions < 0 {
+		stage.Partitions = 0
+	}
+
+	if stage.Partitions > MaxScale {
+		stage.Partitions = MaxScale
+	}
+
 	if stage.Name == "" {
 		stage.Name = "Unnamed"
 	}
@@ -74,6 +257,48 @@ func (stage *Stage) tidy(options *Options) {
 	if stage.CircuitBreaker == nil {
 		stage.CircuitBreaker = options.CircuitBreaker
 	}
+
+	if stage.Execution == DefaultExecution {
+		stage.Execution = options.Execution
+	}
+
+	if stage.Storage == nil {
+		stage.Storage = options.Storage
+	}
+	stage.storageKey = options.Name + "." + stage.Name
+
+	if stage.composite != nil {
+		stage.composite.tidy(options)
+	}
+}
+
+func (stage *Stage) init(arg *stageArg, cache *Cache) {
+	if stage.Storage != nil {
+		items, err := stage.Storage.Restore(stage.storageKey)
+		if err != nil {
+			stage.logger.Error(stage, fmt.Sprintf("restoring cache '%s' failed, starting empty: %s", stage.storageKey, err))
+			arg.runner.fail(fmt.Errorf("%w: restoring cache '%s': %s", ErrStorage, stage.storageKey, err))
+		}
+		cache.restore(items)
+	}
+
+	stage.Init(cache)
+	arg.monitor.init()
+}
+
+func (stage *Stage) dispose(arg *stageArg, cache *Cache) {
+	stage.Dispose(cache)
+	if stage.Storage != nil {
+		items, err := cache.persist()
+		if snapshotErr := stage.Storage.Snapshot(stage.storageKey, items); snapshotErr != nil {
+			err = snapshotErr
+		}
+		if err != nil {
+			stage.logger.Error(stage, fmt.Sprintf("snapshotting cache '%s' failed: %s", stage.storageKey, err))
+			arg.runner.fail(fmt.Errorf("%w: snapshotting cache '%s': %s", ErrStorage, stage.storageKey, err))
+		}
+	}
+	arg.monitor.dispose()
 }
 
 func (stage *Stage) dispatchSource(arg *stageArg) {
@@ -82,132 +307,206 @@ func (stage *Stage) dispatchSource(arg *stageArg) {
 		defer arg.wg.Done()
 
 		parcel := newParcel(nil, stage)
+		parcel.monitor = arg.monitor
+		parcel.ctx = arg.abort
+		parcel.pooled = arg.factory.pooledParcels
 		sourceCtx, sourceCancel := context.WithCancel(arg.ctx)
-		stage.Init(parcel.Cache)
-		defer close(arg.outbound)
+		stage.init(arg, parcel.Cache)
+		defer arg.close()
 		defer sourceCancel()
-		defer stage.Dispose(parcel.Cache)
+		defer stage.dispose(arg, parcel.Cache)
 
-		stage.logger.Information(stage, "source start processing")
-		for result := stage.CircuitBreaker.Execute(stage, parcel); result != Stop; {
+		go func() {
 			select {
+			case <-arg.abort.Done():
+				sourceCancel()
 			case <-sourceCtx.Done():
-				result = Stop
-				continue
-			default:
-				switch value := result.(type) {
-				case Unpack:
-					arg.flushMsg <- &flushMessage{sequence: parcel.Sequence, add: len(value.Data) - 1}
-					for _, data := range value.Data {
-						arg.outbound <- parcel.pack(data)
-					}
-				case Signal:
-					if value == Skip {
-						stage.logger.EnqueueDebug(stage, parcel, fmt.Sprintf("source yielded 'Skip' when processing parcel '%d'", parcel.Sequence))
-					} else if value == Failure {
-						stage.logger.EnqueueDebug(stage, parcel, fmt.Sprintf("source yielded an 'Failure' when processing parcel '%d'", parcel.Sequence))
-					}
-					arg.outbound <- parcel.pack(result)
-				default:
-					arg.outbound <- parcel.pack(result)
-				}
+			}
+		}()
 
-				parcel = parcel.generate(result)
-				result = stage.CircuitBreaker.Execute(stage, parcel)
+		if stage.Partitions > 1 && arg.sequencer == nil {
+			arg.sequencer = &sequencer{}
+		}
+
+		stage.logger.Information(stage, "source start processing")
+		if stage.Partitions <= 1 {
+			stage.produce(arg, sourceCtx, parcel)
+		} else {
+			partitionWg := sync.WaitGroup{}
+			for k := 0; k < stage.Partitions; k++ {
+				partition := *parcel
+				partition.Partition = k
+				partitionWg.Add(1)
+				go func(parcel *Parcel) {
+					defer partitionWg.Done()
+					stage.produce(arg, sourceCtx, parcel)
+				}(&partition)
 			}
+			partitionWg.Wait()
 		}
 
 		stage.logger.Information(stage, "source done processing, quitting")
 	}()
 }
 
+// Generates parcels until the source returns Stop, partitions of a source share its scale.
+func (stage *Stage) produce(arg *stageArg, ctx context.Context, parcel *Parcel) {
+	arg.sequence(parcel)
+	scaled := stage.Partitions > 1
+	for arg.await(ctx, arg.runner.gate, arg.monitor.gate) {
+		if !arg.monitor.limiter.wait(ctx) {
+			break
+		}
+		if scaled && !arg.monitor.semaphore.acquire(ctx) {
+			break
+		}
+
+		arg.monitor.begin()
+		result := stage.CircuitBreaker.Execute(stage, parcel)
+		if scaled {
+			arg.release()
+		}
+		if result == Stop || ctx.Err() != nil || arg.abort.Err() != nil {
+			arg.monitor.end(parcel)
+			break
+		}
+
+		stage.deliver(arg, parcel, result)
+		arg.monitor.end(parcel)
+		next := parcel.generate(result)
+		parcel.release()
+		parcel = next
+		arg.sequence(parcel)
+	}
+}
+
+// Sends the result of a source, returning the number of parcels it was split into.
+func (stage *Stage) deliver(arg *stageArg, parcel *Parcel, result interface{}) int {
+	switch value := result.(type) {
+	case Unpack:
+		arg.flushMsg <- flushMessage{sequence: parcel.Sequence, add: len(value.Data) - 1}
+		for k, data := range value.Data {
+			arg.send(parcel.packChild(data, stage, k))
+		}
+		return len(value.Data)
+	case UnpackIter:
+		return stage.stream(arg, parcel, value)
+	case Signal:
+		if value == Skip {
+			stage.logger.EnqueueDebug(stage, parcel, fmt.Sprintf("source yielded 'Skip' when processing parcel '%d'", parcel.Sequence))
+		} else if value == Failure {
+			stage.logger.EnqueueDebug(stage, parcel, fmt.Sprintf("source yielded an 'Failure' when processing parcel '%d'", parcel.Sequence))
+		}
+		arg.send(parcel.pack(result))
+	default:
+		arg.send(parcel.pack(result))
+	}
+	return 1
+}
+
 func (stage *Stage) dispatchSegment(arg *stageArg) {
 	arg.wg.Add(1)
 	go func() {
 		defer arg.wg.Done()
 
-		parcel := newParcel(nil, stage)
-		semaphore := make(chan struct{}, stage.MaxScale)
-		innerWg := sync.WaitGroup{}
-		stage.Init(parcel.Cache)
-		defer close(arg.outbound)
-		defer stage.Dispose(parcel.Cache)
+		template := newParcel(nil, stage)
+		template.monitor = arg.monitor
+		template.ctx = arg.abort
+		template.branch = arg.branch
+		template.pooled = arg.factory.pooledParcels
+		stage.init(arg, template.Cache)
+		defer arg.close()
+		defer stage.dispose(arg, template.Cache)
+
+		executor := stage.newExecutor(arg, func(parcel *Parcel) {
+			result := stage.CircuitBreaker.Execute(stage, parcel)
+
+			switch value := result.(type) {
+			case Unpack:
+				arg.flushMsg <- flushMessage{sequence: parcel.Sequence, add: len(value.Data) - 1}
+				for k, data := range value.Data {
+					arg.send(parcel.packChild(data, stage, k))
+				}
+			case UnpackIter:
+				stage.stream(arg, parcel, value)
+			default:
+				arg.send(parcel.pack(result))
+			}
+			// The children replace the parcel, an empty split leaves the other branches to resolve the submission
+			arg.track(parcel, -1)
+		})
 
 		stage.logger.Information(stage, "segment start processing")
-		for receivedParcel := range arg.inbound {
-			parcel = parcel.unpack(receivedParcel)
+		arg.receive(func(receivedParcel *Parcel) {
+			parcel := template.unpack(receivedParcel)
+			receivedParcel.release()
 			if parcel.Content == Skip || parcel.Content == Failure {
 				tag := "Skip"
 				if parcel.Content == Failure {
 					tag = "Failure"
 				}
 				stage.logger.EnqueueDebug(stage, parcel, fmt.Sprintf("segment received a parcel tagged '%s'. skipping", tag))
-				arg.outbound <- parcel.pack(parcel.Content)
-				continue
+				arg.send(parcel.pack(parcel.Content))
+				arg.track(parcel, -1)
+				parcel.release()
+				return
 			}
 
-			semaphore <- struct{}{}
-			innerWg.Add(1)
-			go func(parcel *Parcel) {
-				defer innerWg.Done()
-				defer func() { <-semaphore }()
-				result := stage.CircuitBreaker.Execute(stage, parcel)
-
-				switch value := result.(type) {
-				case Unpack:
-					arg.flushMsg <- &flushMessage{sequence: parcel.Sequence, add: len(value.Data) - 1}
-					for _, data := range value.Data {
-						arg.outbound <- parcel.pack(data)
-					}
-				default:
-					arg.outbound <- parcel.pack(result)
-				}
-			}(parcel)
-		}
+			executor.execute(parcel)
+		})
 
 		stage.logger.Information(stage, "segment done processing, quitting")
-		innerWg.Wait()
+		executor.wait()
 	}()
 }
 
 func (stage *Stage) dispatchSink(arg *stageArg) {
 	arg.wg.Add(1)
+	arg.sinks.Add(1)
 	go func() {
 		defer arg.wg.Done()
-
-		semaphore := make(chan struct{}, stage.MaxScale)
-		innerWg := sync.WaitGroup{}
-		parcel := newParcel(nil, stage)
-		stage.Init(parcel.Cache)
-		defer stage.Dispose(parcel.Cache)
+		defer arg.sinks.Done()
+
+		template := newParcel(nil, stage)
+		template.monitor = arg.monitor
+		template.ctx = arg.abort
+		template.branch = arg.branch
+		template.pooled = arg.factory.pooledParcels
+		stage.init(arg, template.Cache)
+		defer stage.dispose(arg, template.Cache)
+
+		executor := stage.newExecutor(arg, func(parcel *Parcel) {
+			arg.resolve(parcel, stage.CircuitBreaker.Execute(stage, parcel))
+			arg.track(parcel, -1)
+			arg.flushMsg <- flushMessage{sequence: parcel.Sequence, add: 1}
+		})
 
 		stage.logger.Information(stage, "sink start processing")
-		for receivedParcel := range arg.inbound {
-			parcel = parcel.unpack(receivedParcel)
+		arg.receive(func(receivedParcel *Parcel) {
+			parcel := template.unpack(receivedParcel)
+			receivedParcel.release()
 
 			if parcel.Content == Skip {
 				stage.logger.EnqueueDebug(stage, parcel, fmt.Sprintf("sink received parcel '%d' tagged 'Skip'. skipping", parcel.Sequence))
-				arg.flushMsg <- &flushMessage{sequence: parcel.Sequence, add: 1}
-				continue
+				arg.resolve(parcel, Skip)
+				arg.track(parcel, -1)
+				arg.flushMsg <- flushMessage{sequence: parcel.Sequence, add: 1}
+				parcel.release()
+				return
 			}
 
 			if parcel.Content == Failure {
 				stage.logger.EnqueueDebug(stage, parcel, fmt.Sprintf("sink received parcel '%d' containing an error. skipping", parcel.Sequence))
-				arg.flushMsg <- &flushMessage{sequence: parcel.Sequence, add: 1}
-				continue
+				arg.resolve(parcel, Failure)
+				arg.track(parcel, -1)
+				arg.flushMsg <- flushMessage{sequence: parcel.Sequence, add: 1}
+				parcel.release()
+				return
 			}
 
-			semaphore <- struct{}{}
-			innerWg.Add(1)
-			go func(parcel *Parcel) {
-				defer innerWg.Done()
-				defer func() { <-semaphore }()
-				stage.CircuitBreaker.Execute(stage, parcel)
-				arg.flushMsg <- &flushMessage{sequence: parcel.Sequence, add: 1}
-			}(parcel)
-		}
-		innerWg.Wait()
-		close(arg.flushMsg)
+			executor.execute(parcel)
+		})
+		executor.wait()
 		stage.logger.Information(stage, "stage done processing, quitting")
 	}()
 }
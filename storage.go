@@ -0,0 +1,316 @@
+package conveyor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Surfaced on Runner.Err() when a stage's cache could not be restored or snapshotted completely.
+var ErrStorage = errors.New("conveyor: storage failed")
+
+// Persists stage caches across runs. The cache is restored before Init and snapshotted after Dispose.
+type IStorage interface {
+	Restore(key string) (map[string]interface{}, error)
+	Snapshot(key string, items map[string]interface{}) error
+}
+
+const DefaultCompactAfter = 16
+
+// Embedded storage keeping an append-only log per stage in a directory. Every snapshot appends the
+// changes since the previous one, the log is compacted into a single record once it grows too long.
+// Values other than builtin types must be registered with gob.Register.
+type FileStorage struct {
+	// Number of records after which a log is compacted, defaults to DefaultCompactAfter
+	CompactAfter int
+
+	dir     string
+	mutex   *sync.Mutex
+	states  map[string]map[string]interface{}
+	records map[string]int
+}
+
+type storageRecord struct {
+	Reset   bool
+	Puts    map[string]interface{}
+	Deletes []string
+}
+
+func NewFileStorage(dir string) (*FileStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("conveyor: creating storage directory: %w", err)
+	}
+
+	return &FileStorage{
+		CompactAfter: DefaultCompactAfter,
+		dir:          dir,
+		mutex:        &sync.Mutex{},
+		states:       make(map[string]map[string]interface{}),
+		records:      make(map[string]int),
+	}, nil
+}
+
+func (storage *FileStorage) Restore(key string) (map[string]interface{}, error) {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	state, records, err := storage.replay(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// The cache owns the restored values, the storage keeps its own copy to diff against
+	items, err := cloneItems(state, nil)
+	if err != nil {
+		return nil, fmt.Errorf("conveyor: copying restored storage '%s': %w", key, err)
+	}
+
+	storage.states[key] = state
+	storage.records[key] = records
+	return items, nil
+}
+
+func (storage *FileStorage) Snapshot(key string, items map[string]interface{}) error {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	previous, ok := storage.states[key]
+	if !ok {
+		state, records, err := storage.replay(key)
+		if err != nil {
+			return err
+		}
+		previous = state
+		storage.records[key] = records
+	}
+
+	// Values failing to encode keep their stored value and are reported once the rest is persisted
+	items, encodeErr := cloneItems(items, previous)
+	if encodeErr != nil {
+		encodeErr = fmt.Errorf("conveyor: encoding storage '%s': %w", key, encodeErr)
+	}
+
+	record := diffItems(previous, items)
+	if len(record.Puts) == 0 && len(record.Deletes) == 0 {
+		storage.states[key] = items
+		return encodeErr
+	}
+
+	var err error
+	if storage.records[key]+1 > storage.compactAfter() {
+		err = storage.compact(key, items)
+	} else {
+		err = storage.append(key, record)
+	}
+	if err != nil {
+		return err
+	}
+
+	storage.states[key] = items
+	return encodeErr
+}
+
+func (storage *FileStorage) compactAfter() int {
+	if storage.CompactAfter <= 0 {
+		return DefaultCompactAfter
+	}
+	return storage.CompactAfter
+}
+
+func (storage *FileStorage) path(key string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(key)
+	return filepath.Join(storage.dir, name+".log")
+}
+
+// Replays the log of the key, a torn record at the tail is truncated away.
+func (storage *FileStorage) replay(key string) (map[string]interface{}, int, error) {
+	state := make(map[string]interface{})
+	file, err := os.OpenFile(storage.path(key), os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, 0, nil
+	} else if err != nil {
+		return nil, 0, fmt.Errorf("conveyor: opening storage log '%s': %w", key, err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	offset, records := int64(0), 0
+	for {
+		record, size, err := readRecord(reader)
+		if err == io.EOF {
+			break
+		} else if errors.Is(err, io.ErrUnexpectedEOF) {
+			if err := file.Truncate(offset); err != nil {
+				return nil, 0, fmt.Errorf("conveyor: truncating torn storage log '%s': %w", key, err)
+			}
+			break
+		} else if err != nil {
+			return nil, 0, fmt.Errorf("conveyor: reading storage log '%s': %w", key, err)
+		}
+
+		record.apply(state)
+		offset += size
+		records++
+	}
+
+	return state, records, nil
+}
+
+func (storage *FileStorage) append(key string, record *storageRecord) error {
+	data, err := encodeRecord(record)
+	if err != nil {
+		return fmt.Errorf("conveyor: encoding storage record '%s': %w", key, err)
+	}
+
+	file, err := os.OpenFile(storage.path(key), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("conveyor: opening storage log '%s': %w", key, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("conveyor: appending storage log '%s': %w", key, err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("conveyor: syncing storage log '%s': %w", key, err)
+	}
+
+	storage.records[key]++
+	return nil
+}
+
+// Rewrites the log as a single record holding the items, replacing the old log atomically.
+func (storage *FileStorage) compact(key string, items map[string]interface{}) error {
+	data, err := encodeRecord(&storageRecord{Reset: true, Puts: items})
+	if err != nil {
+		return fmt.Errorf("conveyor: encoding storage record '%s': %w", key, err)
+	}
+
+	path := storage.path(key)
+	temp := path + ".compact"
+	file, err := os.OpenFile(temp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("conveyor: compacting storage log '%s': %w", key, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("conveyor: compacting storage log '%s': %w", key, err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("conveyor: compacting storage log '%s': %w", key, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("conveyor: compacting storage log '%s': %w", key, err)
+	}
+	if err := os.Rename(temp, path); err != nil {
+		return fmt.Errorf("conveyor: compacting storage log '%s': %w", key, err)
+	}
+
+	storage.records[key] = 1
+	return nil
+}
+
+// Records are a big endian uint32 length followed by the gob encoded record.
+func encodeRecord(record *storageRecord) ([]byte, error) {
+	body := &bytes.Buffer{}
+	if err := gob.NewEncoder(body).Encode(record); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 4, 4+body.Len())
+	binary.BigEndian.PutUint32(data, uint32(body.Len()))
+	return append(data, body.Bytes()...), nil
+}
+
+func readRecord(reader io.Reader) (*storageRecord, int64, error) {
+	header := make([]byte, 4)
+	if n, err := io.ReadFull(reader, header); err == io.EOF {
+		return nil, 0, io.EOF
+	} else if err != nil {
+		return nil, int64(n), io.ErrUnexpectedEOF
+	}
+
+	body := make([]byte, binary.BigEndian.Uint32(header))
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+
+	record := &storageRecord{}
+	if err := gob.NewDecoder(bytes.NewReader(body)).Decode(record); err != nil {
+		return nil, 0, err
+	}
+	return record, int64(4 + len(body)), nil
+}
+
+func (record *storageRecord) apply(state map[string]interface{}) {
+	if record.Reset {
+		for key := range state {
+			delete(state, key)
+		}
+	}
+	for key, value := range record.Puts {
+		state[key] = value
+	}
+	for _, key := range record.Deletes {
+		delete(state, key)
+	}
+}
+
+func diffItems(previous, items map[string]interface{}) *storageRecord {
+	record := &storageRecord{Puts: make(map[string]interface{})}
+	for key, value := range items {
+		if old, ok := previous[key]; !ok || !reflect.DeepEqual(old, value) {
+			record.Puts[key] = value
+		}
+	}
+	for key := range previous {
+		if _, ok := items[key]; !ok {
+			record.Deletes = append(record.Deletes, key)
+		}
+	}
+	return record
+}
+
+type storageValue struct {
+	Value interface{}
+}
+
+// Deep copies the items through gob, so no value is shared between a cache and the storage. A value
+// failing to encode is replaced by its fallback, if any, and named in the error.
+func cloneItems(items, fallback map[string]interface{}) (map[string]interface{}, error) {
+	cloned := make(map[string]interface{}, len(items))
+	failed := make([]string, 0)
+	for key, value := range items {
+		buffer := &bytes.Buffer{}
+		copied := &storageValue{}
+		err := gob.NewEncoder(buffer).Encode(&storageValue{Value: value})
+		if err == nil {
+			err = gob.NewDecoder(buffer).Decode(copied)
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("'%s': %s", key, err))
+			if old, ok := fallback[key]; ok {
+				cloned[key] = old
+			}
+			continue
+		}
+		cloned[key] = copied.Value
+	}
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return cloned, errors.New(strings.Join(failed, "; "))
+	}
+	return cloned, nil
+}
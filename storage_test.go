@@ -0,0 +1,226 @@
+package conveyor
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStorageRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := NewFileStorage(dir)
+	assert.NoError(t, err)
+
+	assert.NoError(t, storage.Snapshot("numbers.sum", map[string]interface{}{"a": 1, "b": "two"}))
+	assert.NoError(t, storage.Snapshot("numbers.sum", map[string]interface{}{"a": 3, "c": 4.5}))
+
+	restored, err := NewFileStorage(dir)
+	assert.NoError(t, err)
+	items, err := restored.Restore("numbers.sum")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 3, "c": 4.5}, items)
+	assert.Equal(t, 2, restored.records["numbers.sum"])
+
+	items, err = restored.Restore("missing")
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestFileStorageCompacts(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := NewFileStorage(dir)
+	assert.NoError(t, err)
+	storage.CompactAfter = 3
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, storage.Snapshot("counter", map[string]interface{}{"count": i}))
+	}
+
+	_, records, err := storage.replay("counter")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, records)
+	items, err := storage.Restore("counter")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"count": 4}, items)
+}
+
+func TestFileStorageTruncatesTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := NewFileStorage(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, storage.Snapshot("torn", map[string]interface{}{"a": 1}))
+
+	file, err := os.OpenFile(storage.path("torn"), os.O_WRONLY|os.O_APPEND, 0)
+	assert.NoError(t, err)
+	_, err = file.Write([]byte{0, 0, 1, 0, 42})
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	restored, err := NewFileStorage(dir)
+	assert.NoError(t, err)
+	items, err := restored.Restore("torn")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1}, items)
+
+	assert.NoError(t, restored.Snapshot("torn", map[string]interface{}{"a": 2}))
+	items, err = restored.Restore("torn")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 2}, items)
+}
+
+func TestFileStorageKeepsInPlaceChanges(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := NewFileStorage(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, storage.Snapshot("offsets", map[string]interface{}{"offsets": []int{1, 2}}))
+
+	items, err := storage.Restore("offsets")
+	assert.NoError(t, err)
+	items["offsets"].([]int)[0] = 5
+	assert.NoError(t, storage.Snapshot("offsets", items))
+
+	restored, err := NewFileStorage(dir)
+	assert.NoError(t, err)
+	items, err = restored.Restore("offsets")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"offsets": []int{5, 2}}, items)
+}
+
+type unregisteredValue struct {
+	Count int
+}
+
+func TestStorageFailuresSurfaceOnRunner(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := NewFileStorage(dir)
+	assert.NoError(t, err)
+
+	runner := New(&Options{Name: "state", Storage: storage}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				return Stop
+			},
+		}).
+		AddSink(&Stage{
+			Name: "count",
+			Init: func(cache *Cache) {
+				cache.Set("count", 1)
+				cache.Set("value", unregisteredValue{Count: 1})
+			},
+		}).Build().DispatchWithTimeout(time.Second)
+	runner.Wait()
+
+	assert.ErrorIs(t, runner.Err(), ErrStorage)
+	assert.Contains(t, runner.Err().Error(), "'value'")
+
+	restored, err := NewFileStorage(dir)
+	assert.NoError(t, err)
+	items, err := restored.Restore("state.count")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"count": 1}, items)
+}
+
+func TestStageCacheSurvivesRestarts(t *testing.T) {
+	storage, err := NewFileStorage(t.TempDir())
+	assert.NoError(t, err)
+
+	run := func(numIter int) []Result {
+		runner := New(&Options{Name: "dedup", Storage: storage, CollectResults: true}).
+			AddSource(&Stage{
+				Process: func(parcel *Parcel) interface{} {
+					if parcel.Sequence >= numIter {
+						return Stop
+					}
+					return parcel.Sequence
+				},
+			}).
+			AddSink(&Stage{
+				Name: "unique",
+				Process: func(parcel *Parcel) interface{} {
+					if !parcel.Cache.SetIfAbsent(string(rune('a'+parcel.Content.(int))), true) {
+						return Skip
+					}
+					return parcel.Content
+				},
+			}).Build().DispatchWithTimeout(time.Second)
+		return Collect(runner, true)
+	}
+
+	unique := func(results []Result) int {
+		count := 0
+		for _, result := range results {
+			if result.Err == nil {
+				count++
+			}
+		}
+		return count
+	}
+
+	assert.Equal(t, 3, unique(run(3)))
+	assert.Equal(t, 2, unique(run(5)))
+}
+
+func TestTypedStoresSurviveRestarts(t *testing.T) {
+	storage, err := NewFileStorage(t.TempDir())
+	assert.NoError(t, err)
+
+	run := func(numIter int) (counts map[int]int, items map[string]interface{}) {
+		New(&Options{Name: "typed", Storage: storage}).
+			AddSource(&Stage{
+				Process: func(parcel *Parcel) interface{} {
+					if parcel.Sequence >= numIter {
+						return Stop
+					}
+					return parcel.Sequence % 2
+				},
+			}).
+			AddSink(&Stage{
+				Name: "count",
+				Process: func(parcel *Parcel) interface{} {
+					Store[int, int](parcel.Cache, "parity", nil).Update(parcel.Content.(int), func(count int, ok bool) int {
+						return count + 1
+					})
+					return nil
+				},
+				Dispose: func(cache *Cache) {
+					counts = make(map[int]int)
+					Store[int, int](cache, "parity", nil).Range(func(key, count int) bool {
+						counts[key] = count
+						return true
+					})
+					items = cache.Items()
+				},
+			}).Build().DispatchWithTimeout(time.Second).Wait()
+		return counts, items
+	}
+
+	counts, _ := run(3)
+	assert.Equal(t, map[int]int{0: 2, 1: 1}, counts)
+	counts, items := run(4)
+	assert.Equal(t, map[int]int{0: 4, 1: 3}, counts)
+	assert.Empty(t, items)
+}
+
+func TestDuplicateStorageKeysPanic(t *testing.T) {
+	storage, err := NewFileStorage(t.TempDir())
+	assert.NoError(t, err)
+
+	assert.Panics(t, func() {
+		New(&Options{Storage: storage}).
+			AddSource(&Stage{}).
+			AddSink(&Stage{}).Build()
+	})
+	assert.Panics(t, func() {
+		New(&Options{Storage: storage}).
+			AddSource(&Stage{Name: "read"}).
+			Fanout(&Stage{Name: "write"}, &Stage{Name: "write"}).
+			AddSinks(&Stage{Name: "left"}, &Stage{Name: "right"}).Build()
+	})
+	assert.NotPanics(t, func() {
+		New(nil).
+			AddSource(&Stage{}).
+			AddSink(&Stage{Storage: storage}).Build()
+	})
+}
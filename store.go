@@ -0,0 +1,297 @@
+package conveyor
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/gob"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Bounds of a state store, the zero value keeps every entry forever and only takes a read lock on reads.
+type StoreOptions struct {
+	// Entries expire once not written for the duration, never when zero
+	TTL time.Duration
+	// Evicts the least recently used entry beyond the size, unbounded when zero
+	MaxSize int
+}
+
+// Typed, concurrency safe key/value store with optional TTL expiry and LRU eviction.
+type StateStore[K comparable, V any] struct {
+	mutex   *sync.RWMutex
+	entries map[K]*list.Element
+	order   *list.List
+	ttl     time.Duration
+	maxSize int
+	now     func() time.Time
+}
+
+// Persisted form of an entry.
+type storeRecord[K comparable, V any] struct {
+	Key     K
+	Value   V
+	Expires time.Time
+}
+
+type storeEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+func NewStateStore[K comparable, V any](opts *StoreOptions) *StateStore[K, V] {
+	store := &StateStore[K, V]{
+		mutex:   &sync.RWMutex{},
+		entries: make(map[K]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+	if opts != nil {
+		store.ttl = opts.TTL
+		store.maxSize = opts.MaxSize
+	}
+	return store
+}
+
+// Named store attached to the stage's cache, created with the options on first use.
+// Usable from Init, Process and Dispose, the type parameters must match on every call. With a
+// Storage the entries are persisted along with the cache and reloaded by the first call.
+func Store[K comparable, V any](cache *Cache, name string, opts *StoreOptions) *StateStore[K, V] {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if existing, ok := cache.stores[name]; ok {
+		store, ok := existing.(*StateStore[K, V])
+		if !ok {
+			panic(fmt.Sprintf("store '%s' was created as '%T'", name, existing))
+		}
+		return store
+	}
+
+	store := NewStateStore[K, V](opts)
+	if data, ok := cache.restored[name]; ok {
+		if err := store.load(data); err != nil {
+			panic(fmt.Sprintf("store '%s' could not be restored as '%T': %s", name, store, err))
+		}
+		delete(cache.restored, name)
+	}
+	cache.stores[name] = store
+	return store
+}
+
+func (store *StateStore[K, V]) Get(key K) (V, bool) {
+	var zero V
+	if store.ttl <= 0 && store.maxSize <= 0 {
+		store.mutex.RLock()
+		defer store.mutex.RUnlock()
+
+		element, ok := store.entries[key]
+		if !ok {
+			return zero, false
+		}
+		return element.Value.(*storeEntry[K, V]).value, true
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry, ok := store.lookup(key)
+	if !ok {
+		return zero, false
+	}
+	return entry.value, true
+}
+
+func (store *StateStore[K, V]) Set(key K, value V) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.write(key, value)
+}
+
+// Atomically replaces the value of the key with the result of fn, ok is false when the key is missing.
+func (store *StateStore[K, V]) Update(key K, fn func(value V, ok bool) V) V {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var current V
+	entry, ok := store.lookup(key)
+	if ok {
+		current = entry.value
+	}
+
+	value := fn(current, ok)
+	store.write(key, value)
+	return value
+}
+
+func (store *StateStore[K, V]) Delete(key K) bool {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	element, ok := store.entries[key]
+	if ok {
+		store.remove(element)
+	}
+	return ok
+}
+
+// Number of unexpired entries.
+func (store *StateStore[K, V]) Len() int {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.purge()
+	return len(store.entries)
+}
+
+func (store *StateStore[K, V]) Keys() []K {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.purge()
+	keys := make([]K, 0, len(store.entries))
+	for element := store.order.Front(); element != nil; element = element.Next() {
+		keys = append(keys, element.Value.(*storeEntry[K, V]).key)
+	}
+	return keys
+}
+
+// Calls fn for every unexpired entry, most recently used first, until it returns false. Without
+// a MaxSize only writes count as use.
+// Iterates a snapshot, fn may modify the store.
+func (store *StateStore[K, V]) Range(fn func(key K, value V) bool) {
+	store.mutex.Lock()
+	store.purge()
+	entries := make([]storeEntry[K, V], 0, len(store.entries))
+	for element := store.order.Front(); element != nil; element = element.Next() {
+		entries = append(entries, *element.Value.(*storeEntry[K, V]))
+	}
+	store.mutex.Unlock()
+
+	for _, entry := range entries {
+		if !fn(entry.key, entry.value) {
+			return
+		}
+	}
+}
+
+// Removes the expired entries, returning how many were removed.
+func (store *StateStore[K, V]) Purge() int {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.purge()
+}
+
+func (store *StateStore[K, V]) Clear() {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.entries = make(map[K]*list.Element)
+	store.order.Init()
+}
+
+// Encodes the unexpired entries, most recently used first.
+func (store *StateStore[K, V]) export() ([]byte, error) {
+	store.mutex.Lock()
+	store.purge()
+	records := make([]storeRecord[K, V], 0, len(store.entries))
+	for element := store.order.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*storeEntry[K, V])
+		records = append(records, storeRecord[K, V]{Key: entry.key, Value: entry.value, Expires: entry.expires})
+	}
+	store.mutex.Unlock()
+
+	buffer := &bytes.Buffer{}
+	if err := gob.NewEncoder(buffer).Encode(records); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// Adds the exported entries behind the present ones, keeping their expiry.
+func (store *StateStore[K, V]) load(data []byte) error {
+	records := make([]storeRecord[K, V], 0)
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&records); err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, record := range records {
+		if _, ok := store.entries[record.Key]; ok {
+			continue
+		}
+		store.entries[record.Key] = store.order.PushBack(&storeEntry[K, V]{key: record.Key, value: record.Value, expires: record.Expires})
+	}
+	for store.maxSize > 0 && len(store.entries) > store.maxSize {
+		store.remove(store.order.Back())
+	}
+	store.purge()
+	return nil
+}
+
+func (store *StateStore[K, V]) lookup(key K) (*storeEntry[K, V], bool) {
+	element, ok := store.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*storeEntry[K, V])
+	if store.expired(entry) {
+		store.remove(element)
+		return nil, false
+	}
+
+	if store.maxSize > 0 {
+		store.order.MoveToFront(element)
+	}
+	return entry, true
+}
+
+func (store *StateStore[K, V]) write(key K, value V) {
+	var expires time.Time
+	if store.ttl > 0 {
+		expires = store.now().Add(store.ttl)
+	}
+
+	if element, ok := store.entries[key]; ok {
+		entry := element.Value.(*storeEntry[K, V])
+		entry.value = value
+		entry.expires = expires
+		store.order.MoveToFront(element)
+		return
+	}
+
+	store.entries[key] = store.order.PushFront(&storeEntry[K, V]{key: key, value: value, expires: expires})
+	for store.maxSize > 0 && len(store.entries) > store.maxSize {
+		store.remove(store.order.Back())
+	}
+}
+
+func (store *StateStore[K, V]) remove(element *list.Element) {
+	delete(store.entries, element.Value.(*storeEntry[K, V]).key)
+	store.order.Remove(element)
+}
+
+func (store *StateStore[K, V]) expired(entry *storeEntry[K, V]) bool {
+	return !entry.expires.IsZero() && !store.now().Before(entry.expires)
+}
+
+func (store *StateStore[K, V]) purge() int {
+	if store.ttl <= 0 {
+		return 0
+	}
+
+	removed := 0
+	for element := store.order.Front(); element != nil; {
+		next := element.Next()
+		if store.expired(element.Value.(*storeEntry[K, V])) {
+			store.remove(element)
+			removed++
+		}
+		element = next
+	}
+	return removed
+}
@@ -0,0 +1,119 @@
+package conveyor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateStoreTypedAccess(t *testing.T) {
+	store := NewStateStore[string, int](nil)
+	store.Set("a", 1)
+	store.Update("a", func(value int, ok bool) int { return value + 1 })
+	store.Update("b", func(value int, ok bool) int {
+		assert.False(t, ok)
+		return 10
+	})
+
+	value, ok := store.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+	assert.Equal(t, 2, store.Len())
+	assert.True(t, store.Delete("b"))
+	assert.False(t, store.Delete("b"))
+	assert.Equal(t, []string{"a"}, store.Keys())
+}
+
+func TestStateStoreExpiresEntries(t *testing.T) {
+	now := time.Now()
+	store := NewStateStore[int, string](&StoreOptions{TTL: time.Minute})
+	store.now = func() time.Time { return now }
+
+	store.Set(1, "one")
+	now = now.Add(30 * time.Second)
+	store.Set(2, "two")
+	now = now.Add(30 * time.Second)
+
+	_, ok := store.Get(1)
+	assert.False(t, ok)
+	_, ok = store.Get(2)
+	assert.True(t, ok)
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, 1, store.Purge())
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestStateStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	store := NewStateStore[int, int](&StoreOptions{MaxSize: 2})
+	store.Set(1, 1)
+	store.Set(2, 2)
+	store.Get(1)
+	store.Set(3, 3)
+
+	_, ok := store.Get(2)
+	assert.False(t, ok)
+	assert.ElementsMatch(t, []int{1, 3}, store.Keys())
+}
+
+func TestStoreAttachedToCache(t *testing.T) {
+	numIter := 50
+	New(nil).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= numIter {
+					return Stop
+				}
+				return parcel.Sequence % 5
+			},
+		}).
+		AddSink(&Stage{
+			MaxScale: 4,
+			Init: func(cache *Cache) {
+				Store[int, int](cache, "counts", nil)
+			},
+			Process: func(parcel *Parcel) interface{} {
+				Store[int, int](parcel.Cache, "counts", nil).Update(parcel.Content.(int), func(count int, ok bool) int {
+					return count + 1
+				})
+				return nil
+			},
+			Dispose: func(cache *Cache) {
+				counts := Store[int, int](cache, "counts", nil)
+				assert.Equal(t, 5, counts.Len())
+				counts.Range(func(key int, count int) bool {
+					assert.Equal(t, numIter/5, count)
+					return true
+				})
+				assert.Panics(t, func() { Store[string, int](cache, "counts", nil) })
+			},
+		}).Build().DispatchBackground().Wait()
+}
+
+func TestCacheKeepsConcurrentMapMethods(t *testing.T) {
+	cache := newCache()
+	cache.MSet(map[string]interface{}{"a": 1, "b": 2})
+	assert.True(t, cache.SetIfAbsent("c", 3))
+	assert.False(t, cache.SetIfAbsent("c", 4))
+	assert.Equal(t, 5, cache.Upsert("a", 4, func(exist bool, valueInMap interface{}, newValue interface{}) interface{} {
+		return valueInMap.(int) + newValue.(int)
+	}))
+
+	value, ok := cache.Pop("b")
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+	assert.False(t, cache.Has("b"))
+	assert.True(t, cache.RemoveCb("c", func(key string, v interface{}, exists bool) bool { return exists }))
+	assert.Equal(t, map[string]interface{}{"a": 5}, cache.Items())
+
+	data, err := cache.MarshalJSON()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"a": 5}`, string(data))
+
+	for tuple := range cache.IterBuffered() {
+		assert.Equal(t, "a", tuple.Key)
+	}
+	cache.Clear()
+	assert.True(t, cache.IsEmpty())
+}
@@ -0,0 +1,184 @@
+package conveyor
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	SourceNode  = "source"
+	SegmentNode = "segment"
+	SinkNode    = "sink"
+
+	DirectConnector = "direct"
+	FanoutConnector = "fanout"
+	FaninConnector  = "fanin"
+	JoinConnector   = "join"
+	KeyedConnector  = "keyed"
+)
+
+// Stage graph of a built conveyor.
+type Topology struct {
+	Name  string         `json:"name"`
+	Nodes []TopologyNode `json:"nodes"`
+	Edges []TopologyEdge `json:"edges"`
+}
+
+type TopologyNode struct {
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	Kind       string         `json:"kind"`
+	Level      int            `json:"level"`
+	Index      int            `json:"index"`
+	MaxScale   uint           `json:"maxScale"`
+	BufferSize uint           `json:"bufferSize"`
+	Inner      []TopologyNode `json:"inner,omitempty"`
+}
+
+type TopologyEdge struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Connector string `json:"connector"`
+	// Parcels per batch of a batched direct edge
+	BatchSize int `json:"batchSize,omitempty"`
+}
+
+func (factory *factory) Describe() *Topology {
+	topology := &Topology{
+		Name:  factory.name,
+		Nodes: make([]TopologyNode, 0),
+		Edges: make([]TopologyEdge, 0),
+	}
+
+	for i, stages := range factory.stages {
+		kind := SegmentNode
+		if i == 0 {
+			kind = SourceNode
+		} else if i == len(factory.stages)-1 {
+			kind = SinkNode
+		}
+
+		for j, stage := range stages {
+			topology.Nodes = append(topology.Nodes, describeStage(nodeID(i, j), kind, i, j, stage))
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		previous := factory.stages[i-1]
+		switch {
+		case len(previous) < len(stages):
+			for j := range stages {
+				topology.Edges = append(topology.Edges, TopologyEdge{From: nodeID(i-1, 0), To: nodeID(i, j), Connector: FanoutConnector})
+			}
+		case len(previous) > len(stages):
+			connector := FaninConnector
+			if stages[0].join != nil {
+				connector = JoinConnector
+			} else if stages[0].keyedJoin != nil {
+				connector = KeyedConnector
+			}
+			for j := range previous {
+				topology.Edges = append(topology.Edges, TopologyEdge{From: nodeID(i-1, j), To: nodeID(i, 0), Connector: connector})
+			}
+		default:
+			for j := range stages {
+				edge := TopologyEdge{From: nodeID(i-1, j), To: nodeID(i, j), Connector: DirectConnector}
+				if batching := factory.batching(i, j); batching != nil {
+					edge.BatchSize = batching.Size
+				}
+				topology.Edges = append(topology.Edges, edge)
+			}
+		}
+	}
+
+	return topology
+}
+
+func describeStage(id, kind string, level, index int, stage *Stage) TopologyNode {
+	node := TopologyNode{
+		ID:         id,
+		Name:       stage.Name,
+		Kind:       kind,
+		Level:      level,
+		Index:      index,
+		MaxScale:   stage.MaxScale,
+		BufferSize: stage.BufferSize,
+	}
+
+	if stage.composite != nil {
+		for k, inner := range stage.composite.stages {
+			node.Inner = append(node.Inner, describeStage(fmt.Sprintf("%s.%d", id, k), SegmentNode, level, index, inner))
+		}
+	}
+
+	return node
+}
+
+func nodeID(level, index int) string {
+	return fmt.Sprintf("%d.%d", level, index)
+}
+
+// Renders the topology as a Graphviz DOT digraph.
+func (topology *Topology) DOT() string {
+	builder := &strings.Builder{}
+	fmt.Fprintf(builder, "digraph %s {\n", dotQuote(topology.Name))
+	builder.WriteString("\trankdir=LR;\n\tnode [shape=box];\n")
+	for _, node := range topology.Nodes {
+		fmt.Fprintf(builder, "\t%s [label=%s];\n", dotQuote(node.ID), dotQuote(strings.Join(node.label(), "\n")))
+	}
+	for _, edge := range topology.Edges {
+		if edge.Connector == DirectConnector {
+			fmt.Fprintf(builder, "\t%s -> %s;\n", dotQuote(edge.From), dotQuote(edge.To))
+		} else {
+			fmt.Fprintf(builder, "\t%s -> %s [label=%s];\n", dotQuote(edge.From), dotQuote(edge.To), dotQuote(edge.Connector))
+		}
+	}
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+// Renders the topology as a Mermaid flowchart.
+func (topology *Topology) Mermaid() string {
+	builder := &strings.Builder{}
+	builder.WriteString("flowchart LR\n")
+	for _, node := range topology.Nodes {
+		fmt.Fprintf(builder, "\t%s[\"%s\"]\n", mermaidID(node.ID), mermaidEscape(strings.Join(node.label(), "<br/>")))
+	}
+	for _, edge := range topology.Edges {
+		if edge.Connector == DirectConnector {
+			fmt.Fprintf(builder, "\t%s --> %s\n", mermaidID(edge.From), mermaidID(edge.To))
+		} else {
+			fmt.Fprintf(builder, "\t%s -->|%s| %s\n", mermaidID(edge.From), edge.Connector, mermaidID(edge.To))
+		}
+	}
+	return builder.String()
+}
+
+func (node *TopologyNode) label() []string {
+	lines := []string{
+		node.Name,
+		fmt.Sprintf("%s, scale: %d, buffer: %d", node.Kind, node.MaxScale, node.BufferSize),
+	}
+	if len(node.Inner) > 0 {
+		names := make([]string, 0, len(node.Inner))
+		for _, inner := range node.Inner {
+			names = append(names, inner.Name)
+		}
+		lines = append(lines, strings.Join(names, " -> "))
+	}
+	return lines
+}
+
+func dotQuote(value string) string {
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n").Replace(value) + "\""
+}
+
+func mermaidID(id string) string {
+	return "n" + strings.ReplaceAll(id, ".", "_")
+}
+
+func mermaidEscape(value string) string {
+	return strings.ReplaceAll(value, "\"", "#quot;")
+}
@@ -0,0 +1,62 @@
+package conveyor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTopologyTestFactory() IFactory {
+	return New(&Options{Name: "numbers"}).
+		AddSource(&Stage{Name: "numerate"}).
+		Fanout(&Stage{Name: "add", BufferSize: 5}, &Stage{Name: "multiply", MaxScale: 4}).
+		Fanin(Compose("sum", nil, &Stage{Name: "validate"}, &Stage{Name: "reduce"})).
+		AddSink(&Stage{Name: "write"}).
+		Build()
+}
+
+func TestDescribeTopology(t *testing.T) {
+	topology := newTopologyTestFactory().Describe()
+
+	assert.Equal(t, "numbers", topology.Name)
+	assert.Len(t, topology.Nodes, 5)
+	assert.Equal(t, SourceNode, topology.Nodes[0].Kind)
+	assert.Equal(t, uint(4), topology.Nodes[2].MaxScale)
+	assert.Equal(t, uint(5), topology.Nodes[1].BufferSize)
+	assert.Len(t, topology.Nodes[3].Inner, 2)
+	assert.Equal(t, SinkNode, topology.Nodes[4].Kind)
+	assert.Equal(t, []TopologyEdge{
+		{From: "0.0", To: "1.0", Connector: FanoutConnector},
+		{From: "0.0", To: "1.1", Connector: FanoutConnector},
+		{From: "1.0", To: "2.0", Connector: FaninConnector},
+		{From: "1.1", To: "2.0", Connector: FaninConnector},
+		{From: "2.0", To: "3.0", Connector: DirectConnector},
+	}, topology.Edges)
+}
+
+func TestRenderTopology(t *testing.T) {
+	topology := newTopologyTestFactory().Describe()
+
+	dot := topology.DOT()
+	assert.Contains(t, dot, "digraph \"numbers\" {")
+	assert.Contains(t, dot, "\"1.1\" [label=\"multiply\\nsegment, scale: 4, buffer: 0\"];")
+	assert.Contains(t, dot, "\"0.0\" -> \"1.0\" [label=\"fanout\"];")
+	assert.Contains(t, dot, "\"2.0\" -> \"3.0\";")
+
+	mermaid := topology.Mermaid()
+	assert.Contains(t, mermaid, "flowchart LR")
+	assert.Contains(t, mermaid, "n2_0[\"sum<br/>segment, scale: 1, buffer: 0<br/>validate -> reduce\"]")
+	assert.Contains(t, mermaid, "n1_0 -->|fanin| n2_0")
+}
+
+func TestDescribeJoinTopology(t *testing.T) {
+	topology := New(nil).
+		AddSource(&Stage{Name: "numerate"}).
+		Fanout(&Stage{Name: "add"}, &Stage{Name: "multiply"}).
+		Join(&Stage{Name: "merge"}, nil).
+		AddSink(&Stage{Name: "write"}).
+		Build().Describe()
+
+	assert.Equal(t, TopologyEdge{From: "1.1", To: "2.0", Connector: JoinConnector}, topology.Edges[3])
+	assert.Contains(t, topology.Mermaid(), "n1_0 -->|join| n2_0")
+}
@@ -1,16 +1,70 @@
 package conveyor
 
+import (
+	"fmt"
+	"runtime/debug"
+)
+
 //
 type Unpack struct {
 	Data []interface{}
 }
 
-//
+// Splits the data into children, a []interface{} is used as is instead of being copied.
 func UnpackData[T any](data []T) Unpack {
-	result := make([]interface{}, 0)
-	for _, content := range data {
-		result = append(result, content)
+	if values, ok := any(data).([]interface{}); ok {
+		return Unpack{Data: values}
+	}
+
+	result := make([]interface{}, len(data))
+	for k, content := range data {
+		result[k] = content
 	}
 
 	return Unpack{Data: result}
 }
+
+// Streams the children of a parcel instead of materializing them. Iter yields the children one
+// at a time, yield blocks while the outbound edge is full and returns false once the conveyor
+// aborted, after which Iter must return. Iter runs after Process returned, outside the stage's
+// retries and timeout, the parcel's context then being the conveyor's rather than the expired
+// attempt's. A panic hands the error to the stage's error handler and sends a Failure child in
+// place of the remaining ones.
+type UnpackIter struct {
+	Iter func(yield func(content interface{}) bool)
+}
+
+// Sends the children of the iterator as they are yielded, returning how many were sent. The flush
+// accounting grows by one for every child beyond the first instead of being known up-front.
+func (stage *Stage) stream(arg *stageArg, parcel *Parcel, value UnpackIter) (sent int) {
+	defer func() {
+		if err := recover(); err != nil {
+			stage.ErrorHandler.Handle(stage, parcel, &Error{
+				Data:  fmt.Errorf("unpack iterator of parcel '%d' panicked: %v", parcel.Sequence, err),
+				Stack: string(debug.Stack()),
+			})
+			if stage.yield(arg, parcel, Failure, sent) {
+				sent++
+			}
+		}
+		if sent == 0 {
+			arg.flushMsg <- flushMessage{sequence: parcel.Sequence, add: -1}
+		}
+	}()
+
+	value.Iter(func(content interface{}) bool {
+		if !stage.yield(arg, parcel, content, sent) {
+			return false
+		}
+		sent++
+		return true
+	})
+	return sent
+}
+
+func (stage *Stage) yield(arg *stageArg, parcel *Parcel, content interface{}, index int) bool {
+	if index > 0 {
+		arg.flushMsg <- flushMessage{sequence: parcel.Sequence, add: 1}
+	}
+	return arg.send(parcel.packChild(content, stage, index))
+}
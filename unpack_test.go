@@ -0,0 +1,236 @@
+package conveyor
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func countTo(n int) UnpackIter {
+	return UnpackIter{Iter: func(yield func(content interface{}) bool) {
+		for k := 0; k < n; k++ {
+			if !yield(k) {
+				return
+			}
+		}
+	}}
+}
+
+func TestUnpackIterStreamsChildren(t *testing.T) {
+	runner := New(&Options{CollectResults: true}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= 3 {
+					return Stop
+				}
+				return countTo(parcel.Sequence + 1)
+			},
+		}).
+		AddStage(&Stage{
+			Name:     "split",
+			MaxScale: 2,
+			Process: func(parcel *Parcel) interface{} {
+				return countTo(parcel.Content.(int))
+			},
+		}).
+		AddSink(&Stage{}).Build().DispatchWithTimeout(5 * time.Second)
+
+	ids := make([]string, 0)
+	for _, result := range Collect(runner, false) {
+		ids = append(ids, result.ID.String())
+	}
+	sort.Strings(ids)
+
+	assert.Equal(t, []string{"1.1.0", "2.1.0", "2.2.0", "2.2.1"}, ids)
+}
+
+func TestUnpackIterAppliesBackpressure(t *testing.T) {
+	numChildren := 1000
+	produced, consumed, outstanding := int64(0), int64(0), int64(0)
+	runner := New(nil).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= 1 {
+					return Stop
+				}
+				return UnpackIter{Iter: func(yield func(content interface{}) bool) {
+					for k := 0; k < numChildren; k++ {
+						pending := atomic.AddInt64(&produced, 1) - atomic.LoadInt64(&consumed)
+						if pending > atomic.LoadInt64(&outstanding) {
+							atomic.StoreInt64(&outstanding, pending)
+						}
+						if !yield(k) {
+							return
+						}
+					}
+				}}
+			},
+		}).
+		AddSink(&Stage{
+			BufferSize: 2,
+			Process: func(parcel *Parcel) interface{} {
+				atomic.AddInt64(&consumed, 1)
+				return nil
+			},
+		}).Build().DispatchWithTimeout(5 * time.Second)
+	runner.Wait()
+
+	assert.Equal(t, int64(numChildren), atomic.LoadInt64(&consumed))
+	assert.LessOrEqual(t, atomic.LoadInt64(&outstanding), int64(8))
+}
+
+func TestUnpackIterStopsOnAbort(t *testing.T) {
+	yielded := int64(0)
+	dispatched := make(chan struct{})
+	var runner *Runner
+	runner = New(nil).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= 1 {
+					return Stop
+				}
+				return UnpackIter{Iter: func(yield func(content interface{}) bool) {
+					for yield(atomic.AddInt64(&yielded, 1)) {
+					}
+				}}
+			},
+		}).
+		AddSink(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Content.(int64) == 10 {
+					<-dispatched
+					runner.abort(errors.New("enough"))
+				}
+				return nil
+			},
+		}).Build().DispatchBackground()
+	close(dispatched)
+	runner.Wait()
+
+	assert.EqualError(t, runner.Err(), "enough")
+	assert.Less(t, atomic.LoadInt64(&yielded), int64(100))
+}
+
+func TestUnpackIterPanicSendsFailure(t *testing.T) {
+	handler := newRecordingErrorHandler()
+	runner := New(&Options{CollectResults: true, ErrorHandler: handler}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= 1 {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddStage(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				return UnpackIter{Iter: func(yield func(content interface{}) bool) {
+					yield("page 1")
+					panic("connection reset")
+				}}
+			},
+		}).
+		AddSink(&Stage{}).Build().DispatchWithTimeout(5 * time.Second)
+
+	results := Collect(runner, true)
+	assert.Len(t, results, 2)
+	assert.Len(t, handler.handled(), 1)
+
+	errs := make([]error, 0)
+	for _, result := range results {
+		errs = append(errs, result.Err)
+	}
+	assert.ElementsMatch(t, []error{nil, ErrFailure}, errs)
+}
+
+func TestCompositeChainsUnpackIter(t *testing.T) {
+	runner := New(&Options{CollectResults: true, PooledParcels: true}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= 2 {
+					return Stop
+				}
+				return parcel.Sequence + 1
+			},
+		}).
+		AddStage(Compose("expand", nil,
+			&Stage{
+				Process: func(parcel *Parcel) interface{} {
+					return countTo(parcel.Content.(int) * 2)
+				},
+			},
+			&Stage{
+				Process: func(parcel *Parcel) interface{} {
+					if parcel.Content.(int)%2 == 1 {
+						return Skip
+					}
+					return UnpackData([]int{parcel.Content.(int), parcel.Content.(int) * 10})
+				},
+			},
+		)).
+		AddSink(&Stage{}).Build().DispatchWithTimeout(5 * time.Second)
+
+	contents, skipped := make([]int, 0), 0
+	for _, result := range Collect(runner, false) {
+		if result.Err == ErrSkipped {
+			skipped++
+			continue
+		}
+		contents = append(contents, result.Content.(int))
+	}
+	sort.Ints(contents)
+
+	assert.Equal(t, 3, skipped)
+	assert.Equal(t, []int{0, 0, 0, 0, 2, 20}, contents)
+}
+
+func TestServedEmptyUnpackIterIsSkipped(t *testing.T) {
+	service := New(nil).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				return countTo(parcel.Content.(int))
+			},
+		}).
+		AddSink(&Stage{}).Build().Serve(context.Background())
+	defer service.Close()
+
+	future, err := service.Submit(context.Background(), 0)
+	assert.NoError(t, err)
+	_, err = future.Get(context.Background())
+	assert.ErrorIs(t, err, ErrSkipped)
+}
+
+func TestUnpackIterOutlivesStageTimeout(t *testing.T) {
+	errs := make([]error, 0)
+	runner := New(&Options{CollectResults: true}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= 1 {
+					return Stop
+				}
+				return parcel.Sequence
+			},
+		}).
+		AddStage(&Stage{
+			Timeout: time.Second,
+			Process: func(parcel *Parcel) interface{} {
+				return UnpackIter{Iter: func(yield func(content interface{}) bool) {
+					for k := 0; k < 3; k++ {
+						errs = append(errs, parcel.Context().Err())
+						if !yield(k) {
+							return
+						}
+					}
+				}}
+			},
+		}).
+		AddSink(&Stage{}).Build().DispatchWithTimeout(5 * time.Second)
+
+	assert.Len(t, Collect(runner, false), 3)
+	assert.Equal(t, []error{nil, nil, nil}, errs)
+}
@@ -0,0 +1,87 @@
+package conveyor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var ErrStalled = errors.New("conveyor: stalled")
+
+// Reports stages which have pending work but made no progress within the timeout.
+type Watchdog struct {
+	// Must be positive
+	Timeout time.Duration
+	// Period between checks, defaults to a quarter of the timeout
+	Interval time.Duration
+	// Aborts the run with an ErrStalled diagnostic surfaced on Runner.Err()
+	Cancel bool
+}
+
+func (watchdog *Watchdog) watch(runner *Runner, logger ILogger) {
+	interval := watchdog.Interval
+	if interval <= 0 {
+		interval = watchdog.Timeout / 4
+	}
+	if interval <= 0 {
+		interval = time.Millisecond
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	reported := make(map[*monitor]int64)
+	for {
+		select {
+		case <-runner.done:
+			return
+		case now := <-ticker.C:
+			if runner.isPaused() {
+				continue
+			}
+
+			diagnostics := make([]string, 0)
+			for _, monitor := range runner.monitors {
+				progress, stalled := monitor.stalled(watchdog.Timeout, now)
+				if !stalled || reported[monitor] == progress {
+					continue
+				}
+
+				reported[monitor] = progress
+				diagnostic := monitor.diagnose(now)
+				logger.Error(monitor.stage, diagnostic)
+				diagnostics = append(diagnostics, diagnostic)
+			}
+
+			if len(diagnostics) > 0 && watchdog.Cancel {
+				runner.abort(fmt.Errorf("%w: %s", ErrStalled, strings.Join(diagnostics, "; ")))
+				return
+			}
+		}
+	}
+}
+
+func (monitor *monitor) stalled(timeout time.Duration, now time.Time) (int64, bool) {
+	progress := atomic.LoadInt64(&monitor.lastProgress)
+	pending := atomic.LoadInt64(&monitor.blockedSending) > 0
+	if monitor.level > 0 {
+		pending = pending || atomic.LoadInt64(&monitor.inFlight) > 0 || len(monitor.inbound) > 0 || len(monitor.inboundBatches) > 0
+	}
+
+	return progress, pending && now.Sub(time.Unix(0, progress)) >= timeout
+}
+
+func (monitor *monitor) diagnose(now time.Time) string {
+	snapshot := monitor.snapshot()
+	return fmt.Sprintf("stage '%s' (%d.%d) made no progress for %s: %d/%d in flight, %d blocked sending, inbound %s, outbound %s",
+		snapshot.Name, snapshot.Level, snapshot.Index, now.Sub(snapshot.LastProgress).Round(time.Millisecond),
+		snapshot.InFlight, snapshot.MaxScale, snapshot.BlockedSending, snapshot.Inbound, snapshot.Outbound)
+}
+
+func (channel ChannelSnapshot) String() string {
+	if channel.Cap > 0 && channel.Len >= channel.Cap {
+		return fmt.Sprintf("%d/%d (full)", channel.Len, channel.Cap)
+	}
+	return fmt.Sprintf("%d/%d", channel.Len, channel.Cap)
+}
@@ -0,0 +1,62 @@
+package conveyor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchdogCancelsStalledConveyor(t *testing.T) {
+	release := make(chan struct{})
+
+	runner := New(&Options{
+		Watchdog: &Watchdog{Timeout: 50 * time.Millisecond, Cancel: true},
+	}).
+		AddSource(&Stage{
+			Name: "numerate",
+			Process: func(parcel *Parcel) interface{} {
+				return parcel.Sequence
+			},
+		}).
+		Fanout(&Stage{Name: "fast"}, &Stage{Name: "slow", BufferSize: 2}).
+		AddSinks(&Stage{Name: "drain"}, &Stage{
+			Name: "stuck",
+			Process: func(parcel *Parcel) interface{} {
+				<-release
+				return nil
+			},
+		}).Build().DispatchBackground()
+
+	assert.Eventually(t, func() bool { return runner.Err() != nil }, 5*time.Second, time.Millisecond)
+	close(release)
+	runner.Wait()
+
+	assert.ErrorIs(t, runner.Err(), ErrStalled)
+	assert.Contains(t, runner.Err().Error(), "stage 'stuck'")
+}
+
+func TestWatchdogIgnoresHealthyConveyor(t *testing.T) {
+	runner := New(&Options{
+		Watchdog: &Watchdog{Timeout: 20 * time.Millisecond, Cancel: true},
+	}).
+		AddSource(&Stage{
+			Process: func(parcel *Parcel) interface{} {
+				if parcel.Sequence >= 5 {
+					return Stop
+				}
+				time.Sleep(30 * time.Millisecond)
+				return parcel.Sequence
+			},
+		}).
+		AddSink(&Stage{}).Build().DispatchBackground()
+
+	runner.Wait()
+	assert.NoError(t, runner.Err())
+}
+
+func TestWatchdogRequiresTimeout(t *testing.T) {
+	assert.Panics(t, func() { New(&Options{Watchdog: &Watchdog{Cancel: true}}) })
+	assert.Panics(t, func() { New(&Options{Watchdog: &Watchdog{Timeout: -time.Second}}) })
+	assert.NotPanics(t, func() { New(&Options{Watchdog: &Watchdog{Timeout: time.Second}}) })
+}